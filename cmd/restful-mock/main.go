@@ -0,0 +1,89 @@
+// Command restful-mock serves the Mocks of fixture files, the same ones Go
+// tests load with rest.LoadMockups, so they could be used from outside Go.
+//
+//	restful-mock -port 8080 -base http://api.com mocks/
+//
+// It listens on 127.0.0.1 only, unless another address is set with -addr,
+// like -addr 0.0.0.0 for every interface.
+//
+// Fixture files are reloaded whenever they change. Every request is logged,
+// with the Mock that matched it, or with the diagnostic if none did.
+//
+// Requests sent to the server are matched as if they were sent to the base
+// URL, so fixtures written for Go tests answer them as well. Without a base
+// URL, they match Mocks whose URL is a path, like /users/{id}.
+//
+// Mocks could be added or cleared at runtime, with the admin endpoints:
+//
+//	POST   /__admin/mocks   adds the Mocks of the fixture in the body
+//	DELETE /__admin/mocks   removes every Mock, until fixtures are reloaded
+//	POST   /__admin/reload  reloads the fixture files
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/go-loco/restful/rest"
+)
+
+// Requests kept by the mockup server, so it doesn't grow forever.
+const maxCalls = 1000
+
+func main() {
+
+	addr := flag.String("addr", "127.0.0.1", "address to listen on")
+	port := flag.Int("port", 8080, "port to listen on")
+	base := flag.String("base", "", "base URL of the requests, like http://api.com")
+	admin := flag.String("admin", "/__admin", "path of the admin endpoints")
+	poll := flag.Duration("poll", time.Second, "how often fixture files are checked for changes, 0 to disable")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [fixture files or directories]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+
+	l, err := net.Listen("tcp", net.JoinHostPort(*addr, strconv.Itoa(*port)))
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	ms := rest.NewMockServer(nil, rest.MockListener(l), rest.MockBaseURL(*base), rest.MockLog(logger),
+		rest.MockCallLimit(maxCalls))
+	defer ms.Close()
+
+	s := newServer(ms, paths, *base, *admin, logger)
+
+	if err := s.reload(); err != nil {
+		logger.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	if *poll > 0 {
+		go s.watch(*poll, stop)
+	}
+
+	logger.Printf("Serving mocks on %s", ms.URL())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-loco/restful/rest"
+)
+
+// server keeps the Mocks of the mockup server: the ones of the fixture
+// files, and the ones added with the admin endpoints.
+type server struct {
+	ms     *rest.MockServer
+	paths  []string
+	base   string
+	admin  string
+	logger *log.Logger
+
+	// Guards added, and the reloads
+	mtx sync.Mutex
+
+	// Mocks of the fixture files, as last loaded.
+	loaded []*rest.Mock
+
+	// Mocks added with the admin endpoints, kept on reload.
+	added []*rest.Mock
+}
+
+func newServer(ms *rest.MockServer, paths []string, base string, admin string, logger *log.Logger) *server {
+	return &server{
+		ms:     ms,
+		paths:  paths,
+		base:   base,
+		admin:  strings.TrimRight(admin, "/"),
+		logger: logger,
+	}
+}
+
+// reload replaces the Mocks of the fixture files. If any file is wrong, the
+// previous Mocks are kept.
+func (s *server) reload() error {
+
+	mocks, err := rest.ReadMockups(s.paths...)
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// The previous Mocks are kept, if the new ones are wrong
+	if err := s.replace(mocks); err != nil {
+		return err
+	}
+
+	s.loaded = mocks
+
+	s.logger.Printf("Loaded %d mocks from %s", len(mocks), strings.Join(s.paths, ", "))
+
+	return nil
+}
+
+// replace replaces every Mock with the Mocks of the fixture files, the added
+// ones, and the admin ones, at once. Scenario states and calls are kept.
+func (s *server) replace(loaded []*rest.Mock) error {
+
+	mocks := append(append(append([]*rest.Mock(nil), loaded...), s.added...), s.adminMocks()...)
+
+	return s.ms.ReplaceMockups(mocks...)
+}
+
+// watch reloads the fixture files whenever they change, until stop is
+// closed.
+func (s *server) watch(interval time.Duration, stop <-chan struct{}) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := s.snapshot()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		current := s.snapshot()
+		if current == last {
+			continue
+		}
+
+		last = current
+
+		if err := s.reload(); err != nil {
+			s.logger.Printf("Fixtures not reloaded: %v", err)
+		}
+	}
+}
+
+// snapshot returns the name, size and modification time of every fixture
+// file, so changes are noticed.
+func (s *server) snapshot() string {
+
+	var files []string
+
+	for _, path := range s.paths {
+		filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				files = append(files, fmt.Sprintf("%s %d %d", file, info.Size(), info.ModTime().UnixNano()))
+			}
+			return nil
+		})
+	}
+
+	sort.Strings(files)
+
+	return strings.Join(files, "\n")
+}
+
+// adminMocks are the Mocks of the admin endpoints.
+func (s *server) adminMocks() []*rest.Mock {
+
+	url := func(path string) string {
+		return strings.TrimRight(s.base, "/") + s.admin + path
+	}
+
+	return []*rest.Mock{
+		{URL: url("/mocks"), HTTPMethod: http.MethodPost, Responder: s.addMocks},
+		{URL: url("/mocks"), HTTPMethod: http.MethodDelete, Responder: s.clearMocks},
+		{URL: url("/reload"), HTTPMethod: http.MethodPost, Responder: s.reloadMocks},
+	}
+}
+
+// addMocks adds the Mocks of the fixture in the body, JSON or YAML.
+func (s *server) addMocks(req *http.Request) rest.MockResponse {
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return adminResponse(http.StatusBadRequest, err.Error())
+	}
+
+	mocks, err := rest.ParseMockups(body)
+	if err != nil {
+		return adminResponse(http.StatusBadRequest, err.Error())
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if err := s.ms.AddMockups(mocks...); err != nil {
+		return adminResponse(http.StatusBadRequest, err.Error())
+	}
+
+	s.added = append(s.added, mocks...)
+
+	s.logger.Printf("Added %d mocks", len(mocks))
+
+	return adminResponse(http.StatusCreated, fmt.Sprintf("added %d mocks", len(mocks)))
+}
+
+// clearMocks removes every Mock, but the admin ones.
+func (s *server) clearMocks(req *http.Request) rest.MockResponse {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	added := s.added
+	s.added = nil
+
+	if err := s.replace(nil); err != nil {
+		s.added = added
+		return adminResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	s.loaded = nil
+
+	s.logger.Print("Cleared mocks")
+
+	return rest.MockResponse{HTTPCode: http.StatusNoContent}
+}
+
+func (s *server) reloadMocks(req *http.Request) rest.MockResponse {
+
+	if err := s.reload(); err != nil {
+		return adminResponse(http.StatusBadRequest, err.Error())
+	}
+
+	return rest.MockResponse{HTTPCode: http.StatusNoContent}
+}
+
+func adminResponse(code int, message string) rest.MockResponse {
+
+	body, _ := json.Marshal(map[string]string{"message": message})
+
+	return rest.MockResponse{
+		HTTPCode: code,
+		Headers:  http.Header{"Content-Type": {"application/json"}},
+		Body:     string(body),
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-loco/restful/rest"
+)
+
+func TestServer(t *testing.T) {
+
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "users.json")
+
+	write := func(body string) {
+		data := `{"url": "http://api.com/users/1", "http_method": "GET", "resp_body": "` + body + `"}`
+		if err := ioutil.WriteFile(fixture, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("first")
+
+	ms := rest.NewMockServer(t, rest.MockBaseURL("http://api.com"))
+	s := newServer(ms, []string{dir}, "http://api.com", "/__admin", log.New(ioutil.Discard, "", 0))
+
+	if err := s.reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(ms.URL() + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if _, body := get("/users/1"); body != "first" {
+		t.Fatal("Wrong response", body)
+	}
+
+	resp, err := http.Post(ms.URL()+"/__admin/mocks", "application/yaml",
+		strings.NewReader("url: http://api.com/ping\nhttp_method: GET\nresp_body: pong\n"))
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatal("Mocks should be added", err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Post(ms.URL()+"/__admin/mocks", "application/json",
+		strings.NewReader(`{"url": "^http://api.com/(", "http_method": "GET"}`))
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("Invalid Mocks should be rejected", err)
+	}
+	resp.Body.Close()
+
+	if len(s.added) != 1 {
+		t.Fatal("Invalid Mocks should not be kept", len(s.added))
+	}
+
+	// Fixture files are reloaded once they change, keeping the added Mocks,
+	// the scenario states and the calls
+	ms.SetScenarioState("user", "created")
+	calls := len(ms.MockCalls())
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go s.watch(10*time.Millisecond, stop)
+
+	time.Sleep(20 * time.Millisecond)
+	write("second")
+	os.Chtimes(fixture, time.Now(), time.Now().Add(time.Second))
+
+	for i := 0; ; i++ {
+		if _, body := get("/users/1"); body == "second" {
+			break
+		}
+		if i == 100 {
+			t.Fatal("Fixtures should be reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if ms.ScenarioState("user") != "created" || len(ms.MockCalls()) <= calls {
+		t.Fatal("Scenario states and calls should be kept")
+	}
+
+	if _, body := get("/ping"); body != "pong" {
+		t.Fatal("Added Mocks should be kept", body)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ms.URL()+"/__admin/mocks", nil)
+	if resp, err = http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatal("Mocks should be cleared", err)
+	}
+	resp.Body.Close()
+
+	if code, _ := get("/ping"); code != rest.StatusMockUnmatched {
+		t.Fatal("Mocks should be cleared", code)
+	}
+}
+
+func TestServerReloadError(t *testing.T) {
+
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "users.json")
+
+	write := func(url string) {
+		data := `{"url": "` + url + `", "http_method": "GET", "resp_body": "user"}`
+		if err := ioutil.WriteFile(fixture, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("http://api.com/users/1")
+
+	ms := rest.NewMockServer(t, rest.MockBaseURL("http://api.com"))
+	s := newServer(ms, []string{dir}, "http://api.com", "/__admin", log.New(ioutil.Discard, "", 0))
+
+	if err := s.reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	write("^http://api.com/(")
+
+	if err := s.reload(); err == nil {
+		t.Fatal("Wrong fixtures should fail")
+	}
+
+	resp, err := http.Get(ms.URL() + "/users/1")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatal("The previous Mocks should be kept", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,3 @@
+module github.com/go-loco/restful
+
+go 1.21
@@ -65,6 +65,42 @@ func setup() {
 
 	//Header
 	tmux.HandleFunc("/header", withHeader)
+
+	//Echo
+	tmux.HandleFunc("/echo", echoRequest)
+	tmux.HandleFunc("/echo/", echoRequest)
+}
+
+// Echo is what the echo handler sends back: the request as it was received.
+type Echo struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+func echoRequest(writer http.ResponseWriter, req *http.Request) {
+
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	echo, _ := json.Marshal(&Echo{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header,
+		Body:   string(b),
+	})
+
+	writer.Header().Set("Content-Type", "application/json")
+
+	if cc := req.URL.Query().Get("cache-control"); cc != "" {
+		writer.Header().Set("Cache-Control", cc)
+	}
+
+	writer.Write(echo)
 }
 
 func withHeader(writer http.ResponseWriter, req *http.Request) {
@@ -0,0 +1,181 @@
+package rest
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnyStale used as CacheControl.MaxStale accepts a cached Response no matter
+// how long it has been expired. It is sent as a bare max-stale directive.
+const AnyStale time.Duration = math.MaxInt64
+
+// StaleCacheRetention is how long an expired Response is kept in the
+// ResourceCache, so it can still be served to requests with a max-stale
+// directive.
+// Default is 0, expired Responses are flushed right away.
+// Type: time.Duration
+var StaleCacheRetention time.Duration
+
+// CacheControl holds the Cache-Control directives of a request.
+// They are honoured by the ResourceCache, and forwarded upstream as the
+// Cache-Control header of the request.
+type CacheControl struct {
+
+	// Don't use a cached Response without revalidating it with the server.
+	NoCache bool
+
+	// Neither read the Response from the cache, nor store it.
+	NoStore bool
+
+	// Use only a cached Response. If there's none, a 504(Gateway Timeout)
+	// Response is returned, and no request is made.
+	OnlyIfCached bool
+
+	// Accept a cached Response that has been expired for at most MaxStale.
+	// Use AnyStale to accept an expired Response of any age.
+	MaxStale time.Duration
+
+	// Accept a cached Response only if it will be still fresh for MinFresh.
+	MinFresh time.Duration
+}
+
+// String returns the directives as a Cache-Control header value.
+func (cc *CacheControl) String() string {
+
+	var directives []string
+
+	if cc.NoCache {
+		directives = append(directives, "no-cache")
+	}
+
+	if cc.NoStore {
+		directives = append(directives, "no-store")
+	}
+
+	if cc.OnlyIfCached {
+		directives = append(directives, "only-if-cached")
+	}
+
+	switch {
+	case cc.MaxStale == AnyStale:
+		directives = append(directives, "max-stale")
+	case cc.MaxStale > 0:
+		directives = append(directives, "max-stale="+seconds(cc.MaxStale))
+	}
+
+	if cc.MinFresh > 0 {
+		directives = append(directives, "min-fresh="+seconds(cc.MinFresh))
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+// parseCacheControl reads the request directives from a Cache-Control header
+// value. Unknown directives are ignored.
+func parseCacheControl(value string) *CacheControl {
+
+	cc := new(CacheControl)
+
+	for _, directive := range strings.Split(value, ",") {
+
+		name, arg := strings.TrimSpace(directive), ""
+		if i := strings.Index(name, "="); i >= 0 {
+			name, arg = strings.TrimSpace(name[:i]), strings.Trim(strings.TrimSpace(name[i+1:]), `"`)
+		}
+
+		switch strings.ToLower(name) {
+		case "no-cache":
+			cc.NoCache = true
+		case "no-store":
+			cc.NoStore = true
+		case "only-if-cached":
+			cc.OnlyIfCached = true
+		case "max-stale":
+			cc.MaxStale = AnyStale
+			if arg != "" {
+				cc.MaxStale = parseSeconds(arg)
+			}
+		case "min-fresh":
+			cc.MinFresh = parseSeconds(arg)
+		}
+	}
+
+	return cc
+}
+
+// cacheControl returns the directives to be used with a request: the ones in
+// the headers of the request itself, or else CacheControl if set, or else the
+// ones in Headers.
+func (rb *RequestBuilder) cacheControl(o *reqOptions) *CacheControl {
+
+	if cc, ok := o.headers["Cache-Control"]; ok {
+		return parseCacheControl(strings.Join(cc, ","))
+	}
+
+	if rb.CacheControl != nil {
+		return rb.CacheControl
+	}
+
+	return parseCacheControl(rb.Headers.Get("Cache-Control"))
+}
+
+// acceptable tells if a cached Response may be used without going to the
+// server, according to the request directives.
+func (r *Response) acceptable(cc *CacheControl) bool {
+
+	if r.ttl == nil {
+		return false
+	}
+
+	left := r.ttl.Sub(time.Now())
+
+	if left >= 0 {
+		return left >= cc.MinFresh
+	}
+
+	return -left <= cc.MaxStale
+}
+
+// gatewayTimeout is the Response for an only-if-cached request that can't be
+// served from the cache.
+func gatewayTimeout(verb string, reqURL string) *Response {
+
+	req, err := http.NewRequest(verb, reqURL, nil)
+	if err != nil {
+		return &Response{Err: err}
+	}
+
+	return &Response{
+		Response: &http.Response{
+			Status:     strconv.Itoa(http.StatusGatewayTimeout) + " " + http.StatusText(http.StatusGatewayTimeout),
+			StatusCode: http.StatusGatewayTimeout,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+			Request:    req,
+		},
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+func parseSeconds(s string) time.Duration {
+
+	secs, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || secs < 0 {
+		return 0
+	}
+
+	if secs > int64(AnyStale/time.Second) {
+		return AnyStale
+	}
+
+	return time.Duration(secs) * time.Second
+}
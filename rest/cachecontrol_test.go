@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCacheControlString(t *testing.T) {
+
+	cc := CacheControl{
+		NoCache:      true,
+		NoStore:      true,
+		OnlyIfCached: true,
+		MaxStale:     10 * time.Second,
+		MinFresh:     5 * time.Second,
+	}
+
+	s := cc.String()
+	if s != "no-cache, no-store, only-if-cached, max-stale=10, min-fresh=5" {
+		t.Fatal("Wrong Cache-Control: " + s)
+	}
+
+	if *parseCacheControl(s) != cc {
+		t.Fatal("Cache-Control parsing failed: " + s)
+	}
+
+	if parseCacheControl("Max-Stale").MaxStale != AnyStale {
+		t.Fatal("max-stale without value should accept any stale response")
+	}
+}
+
+func TestNoDefaultCacheControl(t *testing.T) {
+
+	var echo Echo
+
+	if err := rb.Get("/echo").FillUp(&echo); err != nil {
+		t.Fatal(err)
+	}
+
+	if cc := echo.Header.Get("Cache-Control"); cc != "" {
+		t.Fatal("Cache-Control should not be sent by default. Sent: " + cc)
+	}
+}
+
+func TestCacheControlForwarded(t *testing.T) {
+
+	var echo Echo
+
+	builder := RequestBuilder{
+		BaseURL:      server.URL,
+		CacheControl: &CacheControl{NoCache: true},
+	}
+
+	if err := builder.Get("/echo").FillUp(&echo); err != nil {
+		t.Fatal(err)
+	}
+
+	if cc := echo.Header.Get("Cache-Control"); cc != "no-cache" {
+		t.Fatal("Cache-Control was not forwarded. Sent: " + cc)
+	}
+}
+
+func TestCacheControlNoStore(t *testing.T) {
+
+	builder := RequestBuilder{
+		BaseURL:      server.URL,
+		CacheControl: &CacheControl{NoStore: true},
+	}
+
+	builder.Get("/echo?cache-control=max-age=10&test=no-store")
+
+	if builder.Get("/echo?cache-control=max-age=10&test=no-store").CacheHit() {
+		t.Fatal("no-store Response was cached")
+	}
+
+	if rb.Get("/echo?cache-control=max-age=10&test=no-store").CacheHit() {
+		t.Fatal("no-store Response was cached")
+	}
+}
+
+func TestCacheControlNoCache(t *testing.T) {
+
+	h := make(http.Header)
+	h.Set("Cache-Control", "no-cache")
+
+	builder := RequestBuilder{
+		BaseURL: server.URL,
+		Headers: h,
+	}
+
+	rb.Get("/echo?cache-control=max-age=10&test=no-cache")
+
+	if builder.Get("/echo?cache-control=max-age=10&test=no-cache").CacheHit() {
+		t.Fatal("no-cache Response should not come from the cache")
+	}
+}
+
+func TestCacheControlOnlyIfCached(t *testing.T) {
+
+	builder := RequestBuilder{
+		BaseURL:      server.URL,
+		CacheControl: &CacheControl{OnlyIfCached: true},
+	}
+
+	if resp := builder.Get("/echo?cache-control=max-age=10&test=only-if-cached"); resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatal("Status != Gateway Timeout (504)")
+	}
+
+	rb.Get("/echo?cache-control=max-age=10&test=only-if-cached")
+
+	if resp := builder.Get("/echo?cache-control=max-age=10&test=only-if-cached"); !resp.CacheHit() {
+		t.Fatal("only-if-cached Response should come from the cache")
+	}
+}
+
+func TestCacheControlMinFresh(t *testing.T) {
+
+	builder := RequestBuilder{
+		BaseURL:      server.URL,
+		CacheControl: &CacheControl{MinFresh: 20 * time.Second},
+	}
+
+	rb.Get("/echo?cache-control=max-age=10&test=min-fresh")
+
+	if builder.Get("/echo?cache-control=max-age=10&test=min-fresh").CacheHit() {
+		t.Fatal("Response is not fresh enough to come from the cache")
+	}
+
+	if !rb.Get("/echo?cache-control=max-age=10&test=min-fresh").CacheHit() {
+		t.Fatal("Response should come from the cache")
+	}
+}
+
+func TestCacheControlMaxStale(t *testing.T) {
+
+	scr := StaleCacheRetention
+	defer func() { StaleCacheRetention = scr }()
+
+	StaleCacheRetention = time.Minute
+
+	builder := RequestBuilder{
+		BaseURL:      server.URL,
+		CacheControl: &CacheControl{MaxStale: AnyStale},
+	}
+
+	rb.Get("/echo?cache-control=max-age=1&test=max-stale")
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if !builder.Get("/echo?cache-control=max-age=1&test=max-stale").CacheHit() {
+		t.Fatal("Stale Response should come from the cache")
+	}
+
+	if rb.Get("/echo?cache-control=max-age=1&test=max-stale").CacheHit() {
+		t.Fatal("Stale Response should not come from the cache")
+	}
+}
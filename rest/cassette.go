@@ -0,0 +1,312 @@
+package rest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// CassetteMode tells whether a Cassette records or replays.
+type CassetteMode int
+
+const (
+	// CassetteReplay serves the recorded responses from the mockup server.
+	CassetteReplay CassetteMode = iota
+
+	// CassetteRecord sends the requests to the real server, and records them.
+	CassetteRecord
+)
+
+// Value of the redacted headers in cassettes.
+const redactedValue = "REDACTED"
+
+// Headers always redacted when recording.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Response headers which are not recorded, as the mockup server sets them.
+var unrecordedHeaders = []string{"Content-Length", "Date", "Transfer-Encoding", "Connection"}
+
+// Cassette records the requests made through a RequestBuilder, and their
+// responses, to a file. Then, it replays them through a mockup server, so
+// tests don't need the real server. Responses are replayed by the
+// RequestBuilder MockServer, or by a mockup server of the Cassette, used by
+// its RequestBuilder only, until Close.
+//
+//	rb := &rest.RequestBuilder{
+//		BaseURL:  "https://api.github.com",
+//		Cassette: &rest.Cassette{Path: "testdata/github.json", Mode: rest.CassetteRecord},
+//	}
+//
+// Cassettes are JSON fixture files, so they could be edited, or loaded with
+// LoadMockups as well. Requests recorded many times are replayed as a
+// sequence of Responses. Recording again keeps the requests of the file which
+// are not made this time, and replaces the Responses of the ones which are.
+//
+// NewCassette creates a Cassette which is closed once the test finishes.
+//
+// A Cassette must not be copied after first use.
+type Cassette struct {
+
+	// Path of the cassette file.
+	Path string
+
+	// Record or Replay. Default is CassetteReplay
+	Mode CassetteMode
+
+	// Request headers which must match when replaying.
+	// They are recorded, while others are not. Redacted ones only need to
+	// be present.
+	MatchHeaders []string
+
+	// Do not match the query when replaying.
+	IgnoreQuery bool
+
+	// Do not match the request body when replaying.
+	IgnoreBody bool
+
+	// Headers whose values are replaced by REDACTED when recording, besides
+	// Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+	RedactHeaders []string
+
+	mtx      sync.Mutex
+	fixtures []*mockFixture
+	loadOnce sync.Once
+	loadErr  error
+
+	// Fixtures recorded by this Cassette, rather than read from the file.
+	recorded map[*mockFixture]bool
+
+	// Mockup server replaying the cassette, if the RequestBuilder has none.
+	server *MockServer
+}
+
+// NewCassette creates a Cassette for the file at path, which is closed once
+// the test finishes, if t is not nil.
+//
+//	rb := &rest.RequestBuilder{Cassette: rest.NewCassette(t, "testdata/users.json", rest.CassetteReplay)}
+func NewCassette(t testing.TB, path string, mode CassetteMode) *Cassette {
+
+	c := &Cassette{Path: path, Mode: mode}
+
+	if t != nil {
+		t.Cleanup(c.Close)
+	}
+
+	return c
+}
+
+// Close closes the mockup server of the Cassette, if any.
+func (c *Cassette) Close() {
+
+	c.mtx.Lock()
+	server := c.server
+	c.mtx.Unlock()
+
+	if server != nil {
+		server.Close()
+	}
+}
+
+// replay adds the Mocks of the cassette to the mockup server, once, and
+// returns it. If s is nil, the mockup server of the Cassette is started.
+func (c *Cassette) replay(s *MockServer) (*MockServer, error) {
+
+	c.loadOnce.Do(func() {
+
+		var mocks []*Mock
+		if mocks, c.loadErr = ReadMockups(c.Path); c.loadErr != nil {
+			return
+		}
+
+		// Redacted values can't match, but their headers must be present
+		for _, m := range mocks {
+			for k, values := range m.ReqHeaders {
+				if len(values) == 1 && values[0] == redactedValue {
+					m.present = append(m.present, k)
+					delete(m.ReqHeaders, k)
+				}
+			}
+			sort.Strings(m.present)
+		}
+
+		if s == nil {
+			c.mtx.Lock()
+			c.server = NewMockServer(nil)
+			s = c.server
+			c.mtx.Unlock()
+		}
+
+		c.loadErr = s.AddMockups(mocks...)
+	})
+
+	if s == nil {
+		c.mtx.Lock()
+		s = c.server
+		c.mtx.Unlock()
+	}
+
+	return s, c.loadErr
+}
+
+// record adds a request and its response to the cassette, and saves it.
+func (c *Cassette) record(req *http.Request, reqURL string, reqBody []byte, resp *http.Response, respBody []byte) error {
+
+	fixture := &mockFixture{
+		URL:        reqURL,
+		HTTPMethod: req.Method,
+		ReqHeaders: c.header(req.Header, c.MatchHeaders),
+	}
+
+	if c.IgnoreQuery {
+		if u, err := url.Parse(reqURL); err == nil {
+			u.RawQuery = ""
+			u.ForceQuery = false
+			fixture.URL = "^" + regexp.QuoteMeta(u.String()) + `(\?.*)?$`
+		}
+	}
+
+	if !c.IgnoreBody {
+		fixture.ReqBody = newFixtureBody(reqBody, json.Valid(reqBody))
+	}
+
+	var respHeaders []string
+	for k := range resp.Header {
+		if !match(k, unrecordedHeaders) {
+			respHeaders = append(respHeaders, k)
+		}
+	}
+
+	response := fixtureResponse{
+		HTTPCode: resp.StatusCode,
+		Headers:  c.header(resp.Header, respHeaders),
+		Body:     newFixtureBody(respBody, strings.Contains(resp.Header.Get("Content-Type"), "json")),
+	}
+
+	c.loadOnce.Do(func() {
+		c.fixtures, c.loadErr = readCassette(c.Path)
+	})
+
+	if c.loadErr != nil {
+		return c.loadErr
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.recorded == nil {
+		c.recorded = make(map[*mockFixture]bool)
+	}
+
+	found := false
+
+	for _, f := range c.fixtures {
+		if f.URL == fixture.URL && f.HTTPMethod == fixture.HTTPMethod &&
+			reflect.DeepEqual(f.ReqHeaders, fixture.ReqHeaders) &&
+			f.ReqBody.String() == fixture.ReqBody.String() {
+
+			// Responses read from the file are recorded again
+			if !c.recorded[f] {
+				f.Responses = nil
+				c.recorded[f] = true
+			}
+
+			f.Responses = append(f.Responses, response)
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		fixture.Responses = []fixtureResponse{response}
+		c.fixtures = append(c.fixtures, fixture)
+		c.recorded[fixture] = true
+	}
+
+	data, err := json.MarshalIndent(c.fixtures, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.Path, append(data, '\n'), 0644)
+}
+
+// readCassette reads the fixtures of a cassette file, if it exists.
+func readCassette(path string) ([]*mockFixture, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := splitFixture(path, data, false)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := make([]*mockFixture, len(items))
+
+	for i, it := range items {
+		fixtures[i] = new(mockFixture)
+		if err := json.Unmarshal(it.data, fixtures[i]); err != nil {
+			return nil, &FixtureError{path, it.errorLine(err), err}
+		}
+	}
+
+	return fixtures, nil
+}
+
+// header returns the given headers, redacted.
+func (c *Cassette) header(h http.Header, keys []string) fixtureHeader {
+
+	var header fixtureHeader
+
+	for _, k := range keys {
+
+		values := h.Values(k)
+		if len(values) == 0 {
+			continue
+		}
+
+		if header == nil {
+			header = make(fixtureHeader)
+		}
+
+		k = http.CanonicalHeaderKey(k)
+
+		if c.redacted(k) {
+			values = []string{redactedValue}
+		}
+
+		header[k] = append(fixtureValues(nil), values...)
+	}
+
+	return header
+}
+
+func (c *Cassette) redacted(key string) bool {
+
+	for _, k := range append(redactedHeaders, c.RedactHeaders...) {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+
+	return false
+}
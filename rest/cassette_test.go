@@ -0,0 +1,129 @@
+package rest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "cassettes", "echo.json")
+
+	recorder := &RequestBuilder{
+		BaseURL: server.URL,
+		Cassette: &Cassette{
+			Path:          path,
+			Mode:          CassetteRecord,
+			MatchHeaders:  []string{"X-Id", "X-Secret"},
+			RedactHeaders: []string{"X-Secret"},
+		},
+	}
+
+	recorded := []*Response{
+		recorder.Get("/echo?a=1", WithHeader("X-Id", "1")),
+		recorder.Get("/echo?a=1", WithHeader("X-Id", "1")),
+		recorder.Post("/echo", &User{Name: "Hernan"}, WithHeader("X-Secret", "password")),
+	}
+
+	for _, r := range recorded {
+		if r.Err != nil || r.StatusCode != http.StatusOK {
+			t.Fatal("Recording failed", r.Err)
+		}
+	}
+
+	cassette, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mocks, err := ReadMockups(path)
+	if err != nil || len(mocks) != 2 || len(mocks[0].Responses) != 2 {
+		t.Fatal("Wrong cassette", err, string(cassette))
+	}
+
+	if mocks[1].ReqHeaders.Get("X-Secret") != "REDACTED" {
+		t.Fatal("X-Secret should be redacted")
+	}
+
+	player := &RequestBuilder{
+		BaseURL:  server.URL,
+		Cassette: NewCassette(t, path, CassetteReplay),
+	}
+
+	replayed := []*Response{
+		player.Get("/echo?a=1", WithHeader("X-Id", "1")),
+		player.Get("/echo?a=1", WithHeader("X-Id", "1")),
+		player.Post("/echo", &User{Name: "Hernan"}, WithHeader("X-Secret", "another password")),
+	}
+
+	for i, r := range replayed {
+		if r.Err != nil || r.StatusCode != http.StatusOK || r.String() != recorded[i].String() {
+			t.Fatalf("Response %d: got %d %s, expected %s", i, r.StatusCode, r.String(), recorded[i].String())
+		}
+	}
+
+	if len(player.Cassette.server.MockCalls()) != 3 {
+		t.Fatal("Responses should be replayed by the mockup server of the cassette")
+	}
+
+	if mockUpEnv.Load() || len(MockCalls()) != 0 {
+		t.Fatal("The global mockup server should not be used")
+	}
+
+	if r := Get(server.URL + "/echo?a=1"); r.StatusCode != http.StatusOK {
+		t.Fatal("Other RequestBuilders should not be replayed", r.StatusCode)
+	}
+
+	if r := player.Post("/echo", &User{Name: "Hernan"}); r.StatusCode != StatusMockUnmatched {
+		t.Fatal("Redacted headers should be present")
+	}
+
+	if r := player.Get("/echo?a=2", WithHeader("X-Id", "1")); r.StatusCode != StatusMockUnmatched {
+		t.Fatal("Query should match")
+	}
+
+	ignoring := &RequestBuilder{
+		BaseURL:  server.URL,
+		Cassette: &Cassette{Path: filepath.Join(t.TempDir(), "ignore.json"), Mode: CassetteRecord, IgnoreQuery: true},
+	}
+
+	ignoring.Get("/echo?a=1")
+
+	if mocks, err := ReadMockups(ignoring.Cassette.Path); err != nil || !strings.HasPrefix(mocks[0].URL, "^") {
+		t.Fatal("Query should be ignored", err)
+	}
+}
+
+func TestCassetteRecordAgain(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "again.json")
+
+	record := func(paths ...string) {
+		rb := &RequestBuilder{BaseURL: server.URL, Cassette: NewCassette(t, path, CassetteRecord)}
+
+		for _, p := range paths {
+			if r := rb.Get(p); r.Err != nil {
+				t.Fatal("Recording failed", r.Err)
+			}
+		}
+	}
+
+	record("/echo?a=1", "/echo?a=2", "/echo?a=2")
+	record("/echo?a=2", "/echo?a=3")
+
+	fixtures, err := readCassette(path)
+	if err != nil || len(fixtures) != 3 {
+		t.Fatal("Requests not recorded again should be kept", err, len(fixtures))
+	}
+
+	if !strings.HasSuffix(fixtures[0].URL, "a=1") || !strings.HasSuffix(fixtures[2].URL, "a=3") {
+		t.Fatal("Wrong requests", fixtures[0].URL, fixtures[2].URL)
+	}
+
+	if len(fixtures[0].Responses) != 1 || len(fixtures[1].Responses) != 1 {
+		t.Fatal("Requests recorded again should have their new Responses only", len(fixtures[1].Responses))
+	}
+}
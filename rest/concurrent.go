@@ -49,8 +49,8 @@ type Concurrent struct {
 // In Restful, GET is used for "reading" or retrieving a resource.
 // Client should expect a response status code of 200(OK) if resource exists,
 // 404(Not Found) if it doesn't, or 400(Bad Request).
-func (c *Concurrent) Get(url string) *FutureResponse {
-	return c.doRequest(http.MethodGet, url, nil)
+func (c *Concurrent) Get(url string, opts ...RequestOption) *FutureResponse {
+	return c.doRequest(http.MethodGet, url, nil, opts)
 }
 
 // Post issues a POST HTTP verb to the specified URL, concurrently with any other
@@ -61,8 +61,8 @@ func (c *Concurrent) Get(url string) *FutureResponse {
 // 404(Not Found), or 409(Conflict) if resource already exist.
 //
 // Body could be any of the form: string, []byte, struct & map.
-func (c *Concurrent) Post(url string, body interface{}) *FutureResponse {
-	return c.doRequest(http.MethodPost, url, body)
+func (c *Concurrent) Post(url string, body interface{}, opts ...RequestOption) *FutureResponse {
+	return c.doRequest(http.MethodPost, url, body, opts)
 }
 
 // Patch issues a PATCH HTTP verb to the specified URL, concurrently with any other
@@ -73,8 +73,8 @@ func (c *Concurrent) Post(url string, body interface{}) *FutureResponse {
 // or 400(Bad Request). 200(OK) could be also 204(No Content)
 //
 // Body could be any of the form: string, []byte, struct & map.
-func (c *Concurrent) Patch(url string, body interface{}) *FutureResponse {
-	return c.doRequest(http.MethodPatch, url, body)
+func (c *Concurrent) Patch(url string, body interface{}, opts ...RequestOption) *FutureResponse {
+	return c.doRequest(http.MethodPatch, url, body, opts)
 }
 
 // Put issues a PUT HTTP verb to the specified URL, concurrently with any other
@@ -85,8 +85,8 @@ func (c *Concurrent) Patch(url string, body interface{}) *FutureResponse {
 // or 400(Bad Request). 200(OK) could be also 204(No Content)
 //
 // Body could be any of the form: string, []byte, struct & map.
-func (c *Concurrent) Put(url string, body interface{}) *FutureResponse {
-	return c.doRequest(http.MethodPut, url, body)
+func (c *Concurrent) Put(url string, body interface{}, opts ...RequestOption) *FutureResponse {
+	return c.doRequest(http.MethodPut, url, body, opts)
 }
 
 // Delete issues a DELETE HTTP verb to the specified URL, concurrently with any other
@@ -95,8 +95,8 @@ func (c *Concurrent) Put(url string, body interface{}) *FutureResponse {
 // In Restful, DELETE is used to "delete" a resource.
 // Client should expect a response status code of of 200(OK), 404(Not Found),
 // or 400(Bad Request).
-func (c *Concurrent) Delete(url string) *FutureResponse {
-	return c.doRequest(http.MethodDelete, url, nil)
+func (c *Concurrent) Delete(url string, opts ...RequestOption) *FutureResponse {
+	return c.doRequest(http.MethodDelete, url, nil, opts)
 }
 
 // Head issues a HEAD HTTP verb to the specified URL, concurrently with any other
@@ -105,8 +105,8 @@ func (c *Concurrent) Delete(url string) *FutureResponse {
 // In Restful, HEAD is used to "read" a resource headers only.
 // Client should expect a response status code of 200(OK) if resource exists,
 // 404(Not Found) if it doesn't, or 400(Bad Request).
-func (c *Concurrent) Head(url string) *FutureResponse {
-	return c.doRequest(http.MethodHead, url, nil)
+func (c *Concurrent) Head(url string, opts ...RequestOption) *FutureResponse {
+	return c.doRequest(http.MethodHead, url, nil, opts)
 }
 
 // Options issues a OPTIONS HTTP verb to the specified URL, concurrently with any other
@@ -116,17 +116,17 @@ func (c *Concurrent) Head(url string) *FutureResponse {
 // and supported HTTP verbs.
 // Client should expect a response status code of 200(OK) if resource exists,
 // 404(Not Found) if it doesn't, or 400(Bad Request).
-func (c *Concurrent) Options(url string) *FutureResponse {
-	return c.doRequest(http.MethodOptions, url, nil)
+func (c *Concurrent) Options(url string, opts ...RequestOption) *FutureResponse {
+	return c.doRequest(http.MethodOptions, url, nil, opts)
 }
 
-func (c *Concurrent) doRequest(verb string, url string, reqBody interface{}) *FutureResponse {
+func (c *Concurrent) doRequest(verb string, url string, reqBody interface{}, opts []RequestOption) *FutureResponse {
 
 	fr := new(FutureResponse)
 
 	future := func() {
 		defer c.wg.Done()
-		r := c.reqBuilder.doRequest(verb, url, reqBody)
+		r := c.reqBuilder.doRequest(verb, url, reqBody, opts)
 		atomic.StorePointer(&fr.p, unsafe.Pointer(r))
 	}
 
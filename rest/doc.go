@@ -46,6 +46,14 @@
 // and objects are flushed based on time expiration (TTL) or by hitting the maximum
 // memory limit. In the last case, least accessed objects will be removed first.
 //
+// Responses bigger than MaxCacheEntrySize, or bigger than a tenth of the
+// maximum Memory Size, are never cached.
+//
+// Requests may carry their own cache directives (no-cache, no-store,
+// only-if-cached, max-stale & min-fresh), through the RequestBuilder
+// CacheControl field or a Cache-Control header. They are honoured by the
+// cache, and forwarded upstream.
+//
 // Examples
 //
 // Installation
@@ -119,7 +127,7 @@
 //  fmt.Println("print first")
 //
 // Defaults
-// * Headers: keep-alive, Cache-Control: no-cache
+// * Headers: User-Agent, Accept & Content-Type. Cache-Control only if set
 // * Timeout: 2 seconds
 // * ContentType: JSON (for body requests in POST, PUT and PATCH)
 // * Cache: enable
@@ -149,12 +157,74 @@
 //
 //  resp := rb.Get("/mypath")
 //
+// Request Options
+//
+// Options customize a single request, without changing the RequestBuilder.
+// They are available for synchronous, Fork-Join and Async requests.
+//  resp := rb.Get("/mypath",
+//    rest.WithHeader("X-Request-Id", "1234"),
+//    rest.WithQuery("limit", "10"),
+//    rest.WithTimeout(500*time.Millisecond),
+//    rest.WithoutCache(),
+//  )
+//
+//  resp = rb.Post("/mypath", body,
+//    rest.WithContentType(rest.XML),
+//    rest.WithBasicAuth("user", "password"),
+//  )
+//
+// URL Templates
+//
+// URLs could be RFC 6570 templates, expanded with Params. Values are escaped
+// as needed, and the BaseURL is joined with exactly one slash. Equivalent
+// URLs (e.g. same query parameters in another order) share the same cache
+// entry.
+//  resp := rb.Get("/users/{id}/items{?limit,offset}", rest.Params{
+//    "id":     "hernan",
+//    "limit":  10,
+//    "offset": 20,
+//  })
+//
+// Typed Responses
+//
+// GetAs, PostAs, PutAs, PatchAs and DeleteAs decode the response into a
+// type, and return a single error for the whole request. FutureOf does the
+// same for Fork-Join requests.
+//  user, resp, err := rest.GetAs[User](rb, "/users/1")
+//
+// Status Errors
+//
+// Response.Error returns a *HTTPError when the status code is out of the
+// SuccessRange of the RequestBuilder, 2xx by default.
+//  if err := resp.Error(); rest.IsNotFound(err) {
+//    // ...
+//  }
+//
+// Problem details bodies (RFC 9457) are decoded by Response.Problem.
+//  if p := resp.Problem(); p != nil {
+//    log.Print(p.Title, p.Detail, p.Extensions["balance"])
+//  }
+//
+// Streaming
+//
+// GetStream returns the live body of the response, which the caller must
+// close. Streams are never cached.
+//  resp := rb.GetStream("/exports/1", rest.WithMaxBodySize(1<<30))
+//  if resp.Err == nil {
+//    defer resp.Body.Close()
+//    io.Copy(file, resp.Body)
+//  }
+//
 // Mockups
 //
 // When using mockups, all requests will be sent to the mockup server.
-// To activate the mockup *environment* you have two ways: using the flag -mock
+// To activate the mockup *environment* you have three ways: using the flag
+// -mock, in tests
 //	go test -mock
 //
+// Setting the RESTFUL_MOCK environment variable
+//	RESTFUL_MOCK=1 go test
+//
 // Or by programmatically starting the mockup server
 // 	StartMockupServer()
 // An example
@@ -174,4 +244,70 @@
 //  rest.AddMockups(&mock)
 //
 //  v := rest.Get(myURL)
+//
+// A request matches a Mock when HTTP Method and URL are the same, the query
+// parameters are the same in any order, the request has all the ReqHeaders,
+// and its body matches ReqBody and ReqBodyFunc, if set. If many Mocks match,
+// the most specific one wins.
+//
+// Mock URLs could also be path templates, globs or regular expressions
+// starting with ^. Exact URLs take precedence over patterns.
+//  rest.AddMockups(&rest.Mock{
+//    URL:          "http://api.com/users/{id}",
+//    HTTPMethod:   http.MethodGet,
+//    RespHTTPCode: http.StatusOK,
+//    RespBody:     `{"id": {id}}`,
+//  })
+//
+// A Mock could answer each call with a different response, from its
+// Responses, and Mocks in the same Scenario share a state.
+//
+// Mocks could also wait before answering, with a Latency, or simulate a
+// Fault.
+//
+// A Mock Responder computes the response from the request.
+//
+// The mockup server records every request it receives. Tests verify them
+// with AssertCalled and AssertNoUnmatched, and StrictMockups fails the test
+// if any Mock was never called.
+//  defer rest.FlushMockups()
+//  rest.StrictMockups(t)
+//
+// Requests matching no Mock get a 599 status code (StatusMockUnmatched), with
+// a diagnostic of the closest Mocks in the body.
+//
+// Mocks could also be loaded from JSON or YAML fixture files, or directory
+// trees of them, with LoadMockups.
+//  if err := rest.LoadMockups("fixtures"); err != nil {
+//    t.Fatal(err)
+//  }
+//
+// A Cassette records the requests made through a RequestBuilder, and their
+// responses, to a fixture file, and replays them in CassetteReplay mode.
+//
+// A test could create its own MockServer, closed once the test finishes, and
+// bind it to a RequestBuilder, so tests running in parallel don't share Mocks.
+//  ms := rest.NewMockServer(t)
+//  rb := &rest.RequestBuilder{MockServer: ms}
+//
+// Mockup servers could serve HTTPS as well, with MockTLS.
+//  ms := rest.NewMockServer(t, rest.MockTLS())
+//
+// LoadOpenAPI generates Mocks from an OpenAPI 3 document, JSON or YAML.
+// Operations answer with their lowest 2xx response, or with the response and
+// example requested by the Prefer header (Prefer: code=404, example=missing).
+//  if err := ms.LoadOpenAPI("testdata/openapi.yaml", "http://api.com"); err != nil {
+//    t.Fatal(err)
+//  }
+//
+// ValidateOpenAPI fails the test when requests are not valid for an OpenAPI
+// document.
+//  ms.ValidateOpenAPI(t, "testdata/openapi.json", "http://api.com")
+//
+// For CRUD flows, a MockResource simulates a REST collection, backed by an
+// in-memory store.
+//  ms.AddResource(&rest.MockResource{URL: "http://api.com/users"})
+//
+// Mockup servers could be reached from outside the tests as well, like the
+// restful-mock command does, with MockListener, MockBaseURL and MockLog.
 package rest
@@ -0,0 +1,908 @@
+package rest
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The YAML fixtures are parsed with a parser for the subset of YAML fixtures
+// and OpenAPI documents need: block mappings and sequences, plain and quoted
+// scalars, on one line or many, literal (|) and folded (>) block scalars,
+// flow collections ([a, b] and {a: b}), comments, and many documents
+// separated by ---. Anchors, aliases, tags, merge keys and complex keys are
+// rejected with an error, rather than being misread.
+//
+// Values are the ones encoding/json decodes into an interface{}: maps of
+// strings, slices, strings, float64, bools and nils.
+
+// yamlItem is a document of a YAML file, or an item of a top level sequence,
+// and the line where it starts.
+type yamlItem struct {
+	line  int
+	value interface{}
+
+	// Lines of the keys of value, if it is a mapping
+	keys map[string]int
+}
+
+// yamlError is a syntax error in a YAML file.
+type yamlError struct {
+	line int
+	msg  string
+}
+
+func (e *yamlError) Error() string {
+	return e.msg
+}
+
+type yamlParser struct {
+	lines []string
+
+	// Line number of lines[0]
+	first int
+	pos   int
+
+	// Mappings being parsed, and the lines of the keys of the outermost one
+	depth int
+	keys  map[string]int
+}
+
+// parseYAML parses the documents of a YAML file. The items of top level
+// sequences are returned one by one.
+func parseYAML(data []byte) (items []yamlItem, err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			yerr, ok := r.(*yamlError)
+			if !ok {
+				panic(r)
+			}
+			items, err = nil, yerr
+		}
+	}()
+
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	start := 0
+	directives := true
+
+	for i := 0; i <= len(lines); i++ {
+
+		if i < len(lines) {
+			marker := strings.TrimRight(stripYAMLComment(lines[i]), " \t")
+
+			switch {
+			case directives && strings.HasPrefix(marker, "%"):
+				// Directives, like %YAML 1.2, only come before a ---
+				lines[i] = ""
+				continue
+			case strings.HasPrefix(marker, "--- ") || strings.HasPrefix(marker, "... "):
+				panic(&yamlError{i + 1, "content after a document marker is not supported"})
+			case marker != "---" && marker != "...":
+				if marker != "" {
+					directives = false
+				}
+				continue
+			}
+		}
+
+		p := &yamlParser{lines: lines[start:i], first: start + 1}
+		items = append(items, p.parseDocument()...)
+
+		start = i + 1
+		directives = i < len(lines) && strings.HasPrefix(lines[i], "...")
+	}
+
+	return items, nil
+}
+
+func (p *yamlParser) parseDocument() []yamlItem {
+
+	var items []yamlItem
+
+	indent, text, ok := p.peek()
+
+	switch {
+	case !ok:
+		return nil
+
+	case isYAMLSeqItem(text):
+		for {
+			i, t, ok := p.peek()
+			if !ok || i != indent || !isYAMLSeqItem(t) {
+				break
+			}
+
+			line := p.line()
+			p.keys = nil
+			value := p.parseSeqItem(indent)
+			items = append(items, yamlItem{line, value, p.keys})
+		}
+
+	default:
+		line := p.line()
+		value := p.parseValue(0)
+		items = append(items, yamlItem{line, value, p.keys})
+	}
+
+	if _, _, ok := p.peek(); ok {
+		p.fail(p.line(), "unexpected indentation or content")
+	}
+
+	return items
+}
+
+func (p *yamlParser) fail(line int, format string, args ...interface{}) {
+	panic(&yamlError{line, fmt.Sprintf(format, args...)})
+}
+
+func (p *yamlParser) line() int {
+	return p.first + p.pos
+}
+
+// peek skips blank lines and comments, and returns the indentation and
+// content of the next line, without comments.
+func (p *yamlParser) peek() (indent int, text string, ok bool) {
+
+	for ; p.pos < len(p.lines); p.pos++ {
+
+		raw := p.lines[p.pos]
+		text = strings.TrimLeft(raw, " ")
+		indent = len(raw) - len(text)
+
+		text = strings.TrimRight(stripYAMLComment(text), " \t")
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "\t") {
+			p.fail(p.line(), "tabs are not allowed for indentation")
+		}
+
+		return indent, text, true
+	}
+
+	return 0, "", false
+}
+
+// parseValue parses the value starting at the next line, if it is indented
+// at least min spaces. Otherwise the value is nil.
+func (p *yamlParser) parseValue(min int) interface{} {
+
+	indent, text, ok := p.peek()
+	if !ok || indent < min {
+		return nil
+	}
+
+	switch {
+	case isYAMLSeqItem(text):
+		return p.parseSeq(indent)
+	case yamlMapColon(text) >= 0:
+		return p.parseMap(indent)
+	}
+
+	line := p.line()
+	p.pos++
+
+	return p.parseScalar(text, line, min)
+}
+
+func (p *yamlParser) parseSeq(indent int) []interface{} {
+
+	seq := []interface{}{}
+
+	for {
+		i, text, ok := p.peek()
+		if !ok || i != indent || !isYAMLSeqItem(text) {
+			return seq
+		}
+
+		seq = append(seq, p.parseSeqItem(indent))
+	}
+}
+
+func (p *yamlParser) parseSeqItem(indent int) interface{} {
+
+	raw := p.lines[p.pos]
+
+	// Replace the dash with a space, so the content of the item is parsed as
+	// if it were in its own, more indented, lines.
+	p.lines[p.pos] = raw[:indent] + " " + raw[indent+1:]
+
+	return p.parseValue(indent + 1)
+}
+
+func (p *yamlParser) parseMap(indent int) map[string]interface{} {
+
+	m := make(map[string]interface{})
+
+	p.depth++
+	defer func() { p.depth-- }()
+
+	if p.depth == 1 {
+		p.keys = make(map[string]int)
+	}
+
+	for {
+		i, text, ok := p.peek()
+		if !ok || i != indent || isYAMLSeqItem(text) {
+			return m
+		}
+
+		colon := yamlMapColon(text)
+		if colon < 0 {
+			return m
+		}
+
+		line := p.line()
+		key := p.parseKey(strings.TrimSpace(text[:colon]), line)
+		rest := strings.TrimSpace(text[colon+1:])
+
+		if _, ok := m[key]; ok {
+			p.fail(line, "duplicate key %q", key)
+		}
+
+		if p.depth == 1 {
+			p.keys[key] = line
+		}
+
+		p.pos++
+
+		switch {
+		case rest == "":
+			// A sequence may be as indented as its key
+			if i, t, ok := p.peek(); ok && i == indent && isYAMLSeqItem(t) {
+				m[key] = p.parseSeq(indent)
+			} else {
+				m[key] = p.parseValue(indent + 1)
+			}
+
+		default:
+			m[key] = p.parseScalar(rest, line, indent+1)
+		}
+	}
+}
+
+func (p *yamlParser) parseKey(key string, line int) string {
+
+	switch {
+	case key == "":
+		p.fail(line, "empty keys are not supported")
+	case key[0] == '"' || key[0] == '\'':
+		f := &yamlFlow{p: p, s: key, line: line}
+		s := f.quoted()
+		if f.i < len(key) {
+			p.fail(line, "unexpected %q", key[f.i:])
+		}
+		return s
+	case key == "<<":
+		p.fail(line, "merge keys are not supported")
+	}
+
+	p.checkPlain(key, line)
+
+	return key
+}
+
+// parseScalar parses the value in text, at the given line, which may go on
+// in the next lines indented at least min spaces.
+func (p *yamlParser) parseScalar(text string, line int, min int) interface{} {
+
+	switch text[0] {
+	case '|', '>':
+		return p.parseBlockScalar(min-1, text, line)
+
+	case '[', '{':
+		for yamlFlowDepth(text) > 0 && p.pos < len(p.lines) {
+			next := strings.TrimSpace(stripYAMLComment(p.lines[p.pos]))
+			if next != "" {
+				text += " " + next
+			}
+			p.pos++
+		}
+
+		return p.parseInline(text, line)
+
+	case '"', '\'':
+		return p.parseInline(p.continueQuoted(text), line)
+	}
+
+	p.checkPlain(text, line)
+
+	if yamlMapColon(text) >= 0 {
+		p.fail(line, "mapping values are not allowed here")
+	}
+
+	return resolveYAMLScalar(p.continuePlain(text, min))
+}
+
+// checkPlain fails on the plain scalars starting with an indicator this
+// parser doesn't support.
+func (p *yamlParser) checkPlain(text string, line int) {
+
+	switch {
+	case text[0] == '&':
+		p.fail(line, "anchors are not supported")
+	case text[0] == '*':
+		p.fail(line, "aliases are not supported")
+	case text[0] == '!':
+		p.fail(line, "tags are not supported")
+	case text[0] == '%' || text[0] == '@' || text[0] == '`':
+		p.fail(line, "plain scalars can't start with %q", text[:1])
+	case text == "?" || strings.HasPrefix(text, "? "):
+		p.fail(line, "complex keys are not supported")
+	case isYAMLSeqItem(text):
+		p.fail(line, "sequence entries are not allowed here")
+	}
+}
+
+// continueQuoted joins the lines of a quoted scalar, until its closing
+// quote. Line breaks are folded into spaces, and empty lines into newlines.
+func (p *yamlParser) continueQuoted(text string) string {
+
+	if yamlClosingQuote(text) > 0 {
+		return text
+	}
+
+	line := p.line() - 1
+	breaks := 0
+
+	for ; p.pos < len(p.lines); p.pos++ {
+
+		next := strings.TrimSpace(p.lines[p.pos])
+		if next == "" {
+			breaks++
+			continue
+		}
+
+		if breaks > 0 {
+			text += strings.Repeat("\n", breaks) + next
+		} else {
+			text += " " + next
+		}
+		breaks = 0
+
+		if end := yamlClosingQuote(text); end > 0 {
+			p.pos++
+			return text[:end+1] + stripYAMLComment(text[end+1:])
+		}
+	}
+
+	p.fail(line, "unclosed quoted scalar")
+	return ""
+}
+
+// continuePlain joins the lines of a plain scalar indented at least min
+// spaces. Line breaks are folded into spaces, and empty lines into newlines.
+func (p *yamlParser) continuePlain(text string, min int) string {
+
+	breaks := 0
+
+	for i := p.pos; i < len(p.lines); i++ {
+
+		raw := p.lines[i]
+		next := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(next)
+
+		if strings.TrimSpace(next) == "" {
+			breaks++
+			continue
+		}
+
+		next = strings.TrimRight(stripYAMLComment(next), " \t")
+
+		// A comment ends the scalar
+		if indent < min || next == "" {
+			break
+		}
+
+		if yamlMapColon(next) >= 0 {
+			p.fail(p.first+i, "mapping values are not allowed here")
+		}
+
+		if breaks > 0 {
+			text += strings.Repeat("\n", breaks) + strings.TrimSpace(next)
+		} else {
+			text += " " + strings.TrimSpace(next)
+		}
+
+		breaks = 0
+		p.pos = i + 1
+	}
+
+	return text
+}
+
+// parseBlockScalar parses the lines of a literal (|) or folded (>) block
+// scalar, more indented than its parent.
+func (p *yamlParser) parseBlockScalar(parent int, header string, line int) string {
+
+	chomp := byte(0)
+	blockIndent := -1
+
+	for _, c := range []byte(header[1:]) {
+		switch {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = c
+		case c >= '1' && c <= '9' && blockIndent < 0:
+			blockIndent = int(c - '0')
+			if parent > 0 {
+				blockIndent += parent
+			}
+		default:
+			p.fail(line, "invalid block scalar header %q", header)
+		}
+	}
+
+	var lines []string
+
+	for ; p.pos < len(p.lines); p.pos++ {
+
+		raw := p.lines[p.pos]
+
+		if strings.TrimSpace(raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+
+		i := len(raw) - len(strings.TrimLeft(raw, " "))
+
+		if blockIndent < 0 {
+			if i <= parent {
+				break
+			}
+			blockIndent = i
+		}
+
+		if i < blockIndent {
+			break
+		}
+
+		lines = append(lines, raw[blockIndent:])
+	}
+
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var result strings.Builder
+
+	// Line breaks between two lines that are not more indented are folded
+	// into a space, or dropped if there are empty lines between them.
+	normal := func(l string) bool {
+		return l != "" && l[0] != ' ' && l[0] != '\t'
+	}
+
+	last := -1
+
+	for i, l := range lines {
+
+		if l == "" {
+			if last < 0 {
+				result.WriteByte('\n')
+			}
+			continue
+		}
+
+		if last >= 0 {
+			breaks := i - last
+			if header[0] == '>' && normal(l) && normal(lines[last]) {
+				breaks--
+				if breaks == 0 {
+					result.WriteByte(' ')
+				}
+			}
+			result.WriteString(strings.Repeat("\n", breaks))
+		}
+
+		result.WriteString(l)
+		last = i
+	}
+
+	if last < 0 {
+		if chomp == '+' {
+			return strings.Repeat("\n", len(lines)+trailing)
+		}
+		return ""
+	}
+
+	switch chomp {
+	case 0:
+		result.WriteByte('\n')
+	case '+':
+		result.WriteString(strings.Repeat("\n", trailing+1))
+	}
+
+	return result.String()
+}
+
+// parseInline parses a quoted scalar or a flow collection.
+func (p *yamlParser) parseInline(text string, line int) interface{} {
+
+	f := &yamlFlow{p: p, s: text, line: line}
+
+	v := f.value()
+
+	if f.skipSpaces(); f.i < len(f.s) {
+		p.fail(line, "unexpected %q", f.s[f.i:])
+	}
+
+	return v
+}
+
+type yamlFlow struct {
+	p    *yamlParser
+	s    string
+	i    int
+	line int
+}
+
+func (f *yamlFlow) skipSpaces() {
+	for f.i < len(f.s) && (f.s[f.i] == ' ' || f.s[f.i] == '\t') {
+		f.i++
+	}
+}
+
+func (f *yamlFlow) value() interface{} {
+
+	f.skipSpaces()
+
+	if f.i >= len(f.s) {
+		return nil
+	}
+
+	switch f.s[f.i] {
+	case '[':
+		return f.seq()
+	case '{':
+		return f.mapping()
+	case '"', '\'':
+		return f.quoted()
+	}
+
+	s := f.plain(",]}")
+	if s != "" {
+		f.p.checkPlain(s, f.line)
+	}
+
+	return resolveYAMLScalar(s)
+}
+
+func (f *yamlFlow) plain(stops string) string {
+
+	start := f.i
+
+	for f.i < len(f.s) && strings.IndexByte(stops, f.s[f.i]) < 0 {
+		f.i++
+	}
+
+	return strings.TrimSpace(f.s[start:f.i])
+}
+
+func (f *yamlFlow) seq() []interface{} {
+
+	seq := []interface{}{}
+	f.i++
+
+	for {
+		f.skipSpaces()
+
+		if f.i >= len(f.s) {
+			f.p.fail(f.line, "unclosed flow sequence")
+		}
+
+		if f.s[f.i] == ']' {
+			f.i++
+			return seq
+		}
+
+		seq = append(seq, f.value())
+		f.separator(']')
+	}
+}
+
+func (f *yamlFlow) mapping() map[string]interface{} {
+
+	m := make(map[string]interface{})
+	f.i++
+
+	for {
+		f.skipSpaces()
+
+		if f.i >= len(f.s) {
+			f.p.fail(f.line, "unclosed flow mapping")
+		}
+
+		if f.s[f.i] == '}' {
+			f.i++
+			return m
+		}
+
+		var key string
+		if c := f.s[f.i]; c == '"' || c == '\'' {
+			key = f.quoted()
+			f.skipSpaces()
+		} else {
+			key = f.plain(":,}")
+		}
+
+		if _, ok := m[key]; ok {
+			f.p.fail(f.line, "duplicate key %q", key)
+		}
+
+		switch {
+		case f.i >= len(f.s):
+			f.p.fail(f.line, "unclosed flow mapping")
+		case f.s[f.i] == ':':
+			f.i++
+			m[key] = f.value()
+		default:
+			// A key without a value, like {a}
+			m[key] = nil
+		}
+
+		f.separator('}')
+	}
+}
+
+// separator skips the comma after an item of a flow collection.
+func (f *yamlFlow) separator(end byte) {
+
+	f.skipSpaces()
+
+	switch {
+	case f.i >= len(f.s):
+	case f.s[f.i] == ',':
+		f.i++
+	case f.s[f.i] != end:
+		f.p.fail(f.line, "expected ',' or '%c'", end)
+	}
+}
+
+func (f *yamlFlow) quoted() string {
+
+	end := yamlClosingQuote(f.s[f.i:])
+	if end < 0 {
+		f.p.fail(f.line, "unclosed quoted scalar")
+	}
+
+	quoted := f.s[f.i+1 : f.i+end]
+	f.i += end + 1
+
+	if f.s[f.i-1] == '\'' {
+		return strings.ReplaceAll(quoted, "''", "'")
+	}
+
+	s, err := unescapeYAML(quoted)
+	if err != nil {
+		f.p.fail(f.line, "%v", err)
+	}
+
+	return s
+}
+
+// unescapeYAML replaces the escape sequences of a double quoted scalar.
+func unescapeYAML(s string) (string, error) {
+
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if i++; i >= len(s) {
+			return "", fmt.Errorf("invalid escape at the end of %q", s)
+		}
+
+		size := 0
+
+		switch c := s[i]; c {
+		case '0':
+			b.WriteByte(0)
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 't', '\t':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'v':
+			b.WriteByte('\v')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case 'e':
+			b.WriteByte(0x1b)
+		case ' ', '"', '/', '\\':
+			b.WriteByte(c)
+		case 'N':
+			b.WriteString("\u0085")
+		case '_':
+			b.WriteString("\u00a0")
+		case 'L':
+			b.WriteString("\u2028")
+		case 'P':
+			b.WriteString("\u2029")
+		case 'x':
+			size = 2
+		case 'u':
+			size = 4
+		case 'U':
+			size = 8
+		default:
+			return "", fmt.Errorf("invalid escape \\%c", c)
+		}
+
+		if size == 0 {
+			continue
+		}
+
+		if i+size >= len(s) {
+			return "", fmt.Errorf("invalid escape \\%s", s[i:])
+		}
+
+		r, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return "", fmt.Errorf("invalid escape \\%s", s[i:i+1+size])
+		}
+
+		b.WriteRune(rune(r))
+		i += size
+	}
+
+	return b.String(), nil
+}
+
+var (
+	yamlNumber = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	yamlInf    = regexp.MustCompile(`^[-+]?\.(inf|Inf|INF)$`)
+)
+
+// resolveYAMLScalar returns the value of a plain scalar: nil, bool, float64
+// or string.
+func resolveYAMLScalar(s string) interface{} {
+
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	case ".nan", ".NaN", ".NAN":
+		return math.NaN()
+	}
+
+	switch {
+	case yamlNumber.MatchString(s):
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case yamlInf.MatchString(s):
+		if s[0] == '-' {
+			return math.Inf(-1)
+		}
+		return math.Inf(1)
+	case strings.HasPrefix(s, "0x"), strings.HasPrefix(s, "0o"):
+		base := 16
+		if s[1] == 'o' {
+			base = 8
+		}
+		if i, err := strconv.ParseUint(s[2:], base, 64); err == nil {
+			return float64(i)
+		}
+	}
+
+	return s
+}
+
+func isYAMLSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// yamlMapColon returns the index of the colon after the key, if the line is
+// a mapping entry. Otherwise it returns -1.
+func yamlMapColon(text string) int {
+
+	i := 0
+
+	switch {
+	case text == "":
+		return -1
+	case text[0] == '[' || text[0] == '{':
+		return -1
+	case text[0] == '"' || text[0] == '\'':
+		if i = yamlClosingQuote(text) + 1; i == 0 {
+			return -1
+		}
+	}
+
+	for ; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t') {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// yamlFlowDepth returns how many flow collections in s are not closed.
+func yamlFlowDepth(s string) int {
+
+	depth := 0
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case '"', '\'':
+			end := yamlClosingQuote(s[i:])
+			if end < 0 {
+				return depth
+			}
+			i += end
+		}
+	}
+
+	return depth
+}
+
+// yamlClosingQuote returns the index of the quote closing the quoted scalar
+// at the start of s, or -1.
+func yamlClosingQuote(s string) int {
+
+	quote := s[0]
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote && quote == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// stripYAMLComment removes a comment from a line, if any.
+func stripYAMLComment(line string) string {
+
+	for i := 0; i < len(line); i++ {
+
+		switch c := line[i]; {
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,:-", line[i-1]) >= 0):
+			end := yamlClosingQuote(line[i:])
+			if end < 0 {
+				// The rest of the line is quoted, and goes on in the next one
+				return line
+			}
+			i += end
+		}
+	}
+
+	return line
+}
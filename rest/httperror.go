@@ -0,0 +1,157 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// DefaultSuccessRange is the success range of RequestBuilders without one:
+// every 2xx status code.
+var DefaultSuccessRange = StatusRange{Min: http.StatusOK, Max: 299}
+
+// StatusRange is a range of status codes, both ends included.
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// Contains tells if the status code is in the range.
+func (sr StatusRange) Contains(code int) bool {
+	return code >= sr.Min && code <= sr.Max
+}
+
+// HTTPError is the error of a response whose status code is out of the
+// success range of its RequestBuilder.
+//
+//	var httpErr *rest.HTTPError
+//	if errors.As(resp.Error(), &httpErr) {
+//		log.Print(httpErr.StatusCode, httpErr.Payload)
+//	}
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+
+	// The body, decoded. It is what the ErrorPayload of the RequestBuilder
+	// returns, if set. Otherwise, a *Problem for problem details bodies, or
+	// the body decoded from JSON. Nil if it couldn't be decoded.
+	Payload interface{}
+}
+
+func (e *HTTPError) Error() string {
+	return "HTTP status " + e.Status
+}
+
+func newHTTPError(r *Response) *HTTPError {
+
+	e := &HTTPError{
+		StatusCode: r.StatusCode,
+		Status:     r.Status,
+		Header:     r.Header,
+		Body:       r.byteBody,
+	}
+
+	if r.errorPayload != nil {
+		if payload := r.errorPayload(); r.FillUp(payload) == nil {
+			e.Payload = payload
+		}
+		return e
+	}
+
+	if problem := r.Problem(); problem != nil {
+		e.Payload = problem
+		return e
+	}
+
+	if isJSONMediaType(r.Header.Get("Content-Type")) {
+		var payload interface{}
+		if json.Unmarshal(r.byteBody, &payload) == nil {
+			e.Payload = payload
+		}
+	}
+
+	return e
+}
+
+// Error returns the error of the response: Err, if the request failed, or a
+// *HTTPError, if its status code is out of the success range of its
+// RequestBuilder. Otherwise it returns nil.
+func (r *Response) Error() error {
+
+	switch {
+	case r.Err != nil:
+		return r.Err
+	case r.Response == nil || r.successRange().Contains(r.StatusCode):
+		return nil
+	}
+
+	return newHTTPError(r)
+}
+
+func (r *Response) successRange() StatusRange {
+
+	if r.success == (StatusRange{}) {
+		return DefaultSuccessRange
+	}
+
+	return r.success
+}
+
+// IsNotFound tells if the error is a *HTTPError with status code 404.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized tells if the error is a *HTTPError with status code 401.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden tells if the error is a *HTTPError with status code 403.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsConflict tells if the error is a *HTTPError with status code 409.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsServerError tells if the error is a *HTTPError with a 5xx status code.
+func IsServerError(err error) bool {
+
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode >= 500 && httpErr.StatusCode <= 599
+}
+
+// IsRetryable tells if the request could succeed if retried: the error is a
+// timeout, or a *HTTPError with status code 408, 425, 429, 502, 503 or 504.
+func IsRetryable(err error) bool {
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+
+	switch httpErr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+func hasStatus(err error, code int) bool {
+
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == code
+}
@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestResponseError(t *testing.T) {
+
+	ms := NewMockServer(t)
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/errors/missing",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusNotFound,
+		RespHeaders:  http.Header{"Content-Type": {"application/json"}, "Cache-Control": {"max-age=60"}},
+		RespBody:     `{"message":"not found"}`,
+	}, &Mock{
+		URL:          "http://mytest.com/errors/unavailable",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusServiceUnavailable,
+	}, &Mock{
+		URL:          "http://mytest.com/errors/ok",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+	})
+
+	rb := &RequestBuilder{MockServer: ms}
+
+	resp := rb.Get("http://mytest.com/errors/missing")
+	if resp.Err != nil {
+		t.Fatal("Err should only be set with StatusErrors", resp.Err)
+	}
+
+	var httpErr *HTTPError
+	if err := resp.Error(); !errors.As(err, &httpErr) || !IsNotFound(err) || IsRetryable(err) {
+		t.Fatal("Wrong error", err)
+	}
+
+	if payload, ok := httpErr.Payload.(map[string]interface{}); !ok || payload["message"] != "not found" ||
+		httpErr.Header.Get("Content-Type") != "application/json" || string(httpErr.Body) != `{"message":"not found"}` {
+		t.Fatal("Wrong HTTPError", httpErr)
+	}
+
+	if err := rb.Get("http://mytest.com/errors/unavailable").Error(); !IsRetryable(err) || !IsServerError(err) {
+		t.Fatal("Wrong error", err)
+	}
+
+	if err := rb.Get("http://mytest.com/errors/ok").Error(); err != nil {
+		t.Fatal("Successful responses should have no error", err)
+	}
+
+	// The same cached response, with another success range
+	lenient := &RequestBuilder{MockServer: ms, SuccessRange: StatusRange{Min: 200, Max: 404}, StatusErrors: true}
+
+	if resp = lenient.Get("http://mytest.com/errors/missing"); !resp.CacheHit() || resp.Err != nil || resp.Error() != nil {
+		t.Fatal("404 should be a success", resp.Err)
+	}
+
+	strict := &RequestBuilder{MockServer: ms, StatusErrors: true}
+
+	if resp = strict.Get("http://mytest.com/errors/missing"); !resp.CacheHit() || !IsNotFound(resp.Err) {
+		t.Fatal("Err should be set with StatusErrors", resp.Err)
+	}
+
+	if resp = rb.Get("http://mytest.com/errors/missing"); resp.Err != nil {
+		t.Fatal("Cached responses should not be shared", resp.Err)
+	}
+}
@@ -0,0 +1,234 @@
+package rest
+
+import (
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+)
+
+// MockCall is a request received by the mockup server.
+type MockCall struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+	Time   time.Time
+
+	// Mock that answered the request. Nil if no Mock matched it.
+	Mock *Mock
+
+	// Why no Mock matched the request, listing the closest Mocks, and what
+	// is different in each one. Empty if a Mock matched it.
+	Diagnostic string
+
+	// How the request violates the OpenAPI contract, if it is validated
+	// with ValidateOpenAPI.
+	Violations []string
+}
+
+// recordCall records a request, and the Mock that matched it, if any.
+// s.mtx must be held.
+func (s *MockServer) recordCall(req *http.Request, body []byte, m *Mock, diagnostic string, violations []string) {
+
+	header := req.Header.Clone()
+	header.Del("X-Original-URL")
+
+	s.calls = append(s.calls, MockCall{
+		Method:     req.Method,
+		URL:        req.Header.Get("X-Original-URL"),
+		Header:     header,
+		Body:       body,
+		Time:       time.Now(),
+		Mock:       m,
+		Diagnostic: diagnostic,
+		Violations: violations,
+	})
+
+	if s.maxCalls > 0 && len(s.calls) > s.maxCalls {
+		s.calls = s.calls[len(s.calls)-s.maxCalls:]
+	}
+}
+
+// MockCallLimit keeps only the latest n calls, so long running servers don't
+// grow forever. Older calls are not returned by MockCalls, nor reported by
+// AssertNoUnmatched. Zero means no limit.
+func MockCallLimit(n int) MockServerOption {
+	return mockOptionFunc(func(s *MockServer) {
+		s.maxCalls = n
+	})
+}
+
+// MockCalls returns every request received by the global mockup server,
+// in order.
+func MockCalls() []MockCall {
+	return defaultMockServer.MockCalls()
+}
+
+// Calls returns the requests answered by the Mock in the global mockup
+// server, in order.
+func Calls(mock *Mock) []MockCall {
+	return defaultMockServer.Calls(mock)
+}
+
+// UnmatchedCalls returns the requests that no Mock of the global mockup
+// server matched, in order.
+func UnmatchedCalls() []MockCall {
+	return defaultMockServer.UnmatchedCalls()
+}
+
+// AssertCalled fails the test if the Mock wasn't called exactly that many
+// times by the global mockup server.
+func AssertCalled(t testing.TB, mock *Mock, times int) {
+	t.Helper()
+	defaultMockServer.AssertCalled(t, mock, times)
+}
+
+// AssertNoUnmatched fails the test if any request to the global mockup server
+// didn't match a Mock.
+func AssertNoUnmatched(t testing.TB) {
+	t.Helper()
+	defaultMockServer.AssertNoUnmatched(t)
+}
+
+// StrictMockups makes the global mockup server strict.
+// See MockServer.Strict
+func StrictMockups(t testing.TB) {
+	t.Helper()
+	defaultMockServer.Strict(t)
+}
+
+// MockCalls returns every request received, in order.
+func (s *MockServer) MockCalls() []MockCall {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return append([]MockCall(nil), s.calls...)
+}
+
+// Calls returns the requests answered by the Mock, in order.
+func (s *MockServer) Calls(mock *Mock) []MockCall {
+	return s.filterCalls(func(c *MockCall) bool { return c.Mock == mock })
+}
+
+// UnmatchedCalls returns the requests that no Mock matched, in order.
+func (s *MockServer) UnmatchedCalls() []MockCall {
+	return s.filterCalls(func(c *MockCall) bool { return c.Mock == nil })
+}
+
+func (s *MockServer) filterCalls(f func(*MockCall) bool) []MockCall {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var calls []MockCall
+
+	for i := range s.calls {
+		if f(&s.calls[i]) {
+			calls = append(calls, s.calls[i])
+		}
+	}
+
+	return calls
+}
+
+// AssertCalled fails the test if the Mock wasn't called exactly that many
+// times. Calls are counted by the Mock, so they are not bound by
+// MockCallLimit.
+func (s *MockServer) AssertCalled(t testing.TB, mock *Mock, times int) {
+	t.Helper()
+
+	s.mtx.Lock()
+	calls := mock.calls
+	s.mtx.Unlock()
+
+	if calls != times {
+		t.Errorf("Mock %s %s called %d times, expected %d", mock.HTTPMethod, mock.URL, calls, times)
+	}
+}
+
+// AssertNoUnmatched fails the test if any request didn't match a Mock, with
+// the diagnostic of each one.
+func (s *MockServer) AssertNoUnmatched(t testing.TB) {
+	t.Helper()
+
+	for _, c := range s.UnmatchedCalls() {
+		t.Errorf("%s", c.Diagnostic)
+	}
+}
+
+// Strict fails the test if any of the Mocks added wasn't called, or if any
+// request didn't match a Mock. It is checked when the Mocks are flushed, and
+// once the test has finished. Mocks generated from OpenAPI documents are not
+// required to be called.
+//
+//	defer rest.FlushMockups()
+//	rest.StrictMockups(t)
+func (s *MockServer) Strict(t testing.TB) {
+	t.Helper()
+
+	s.mtx.Lock()
+	s.strictTB = t
+	s.mtx.Unlock()
+
+	t.Cleanup(func() {
+		t.Helper()
+
+		s.mtx.Lock()
+		if s.strictTB == t {
+			s.strictTB = nil
+		}
+		s.mtx.Unlock()
+
+		s.checkStrict(t)
+	})
+}
+
+// checkStrict fails the test if any Mock wasn't called, or if any request
+// didn't match a Mock.
+func (s *MockServer) checkStrict(t testing.TB) {
+	t.Helper()
+
+	for _, m := range s.unusedMocks() {
+		t.Errorf("Mock %s %s was never called", m.HTTPMethod, m.URL)
+	}
+
+	s.AssertNoUnmatched(t)
+}
+
+// unusedMocks returns the Mocks that haven't been called, but the generated
+// ones.
+func (s *MockServer) unusedMocks() []*Mock {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var unused []*Mock
+
+	for _, mocks := range s.mocks {
+		for _, m := range mocks {
+			if m.calls == 0 && !m.generated {
+				unused = append(unused, m)
+			}
+		}
+	}
+
+	for _, m := range s.patterns {
+		if m.calls == 0 && !m.generated {
+			unused = append(unused, m)
+		}
+	}
+
+	sort.SliceStable(unused, func(i, j int) bool {
+		a, b := unused[i], unused[j]
+
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+
+		return a.HTTPMethod < b.HTTPMethod
+	})
+
+	return unused
+}
@@ -0,0 +1,168 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// recordingTB records the errors instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func TestMockupCalls(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	post := &Mock{
+		URL:          "http://mytest.com/calls",
+		HTTPMethod:   http.MethodPost,
+		RespHTTPCode: http.StatusCreated,
+	}
+	get := &Mock{
+		URL:          "http://mytest.com/calls",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+	}
+
+	AddMockups(post, get)
+
+	Post("http://mytest.com/calls", &User{Name: "Hernan"}, WithHeader("X-Id", "1"))
+	Post("http://mytest.com/calls", &User{Name: "Juan"})
+
+	calls := Calls(post)
+	if len(calls) != 2 {
+		t.Fatal("Expected 2 calls, got", len(calls))
+	}
+
+	if calls[0].Method != http.MethodPost || calls[0].URL != "http://mytest.com/calls" ||
+		calls[0].Header.Get("X-Id") != "1" || string(calls[0].Body) != `{"id":0,"name":"Hernan"}` ||
+		calls[0].Mock != post || calls[0].Time.IsZero() {
+		t.Fatalf("Wrong call %+v", calls[0])
+	}
+
+	if calls[0].Header.Get("X-Original-URL") != "" {
+		t.Fatal("X-Original-URL should not be recorded")
+	}
+
+	if calls[1].Time.Before(calls[0].Time) {
+		t.Fatal("Calls should be in order")
+	}
+
+	AssertCalled(t, post, 2)
+	AssertCalled(t, get, 0)
+	AssertNoUnmatched(t)
+
+	tb := &recordingTB{TB: t}
+	AssertCalled(tb, post, 1)
+	if len(tb.errors) != 1 {
+		t.Fatal("AssertCalled should fail")
+	}
+
+	Get("http://mytest.com/unmatched")
+
+	tb = &recordingTB{TB: t}
+	AssertNoUnmatched(tb)
+	if len(tb.errors) != 1 || len(UnmatchedCalls()) != 1 || len(MockCalls()) != 3 {
+		t.Fatal("AssertNoUnmatched should fail", tb.errors)
+	}
+
+	FlushMockups()
+
+	if len(MockCalls()) != 0 {
+		t.Fatal("FlushMockups should remove the calls")
+	}
+}
+
+func TestStrictMockups(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+
+	tb := &recordingTB{TB: t}
+	StrictMockups(tb)
+
+	AddMockups(
+		&Mock{
+			URL:          "http://mytest.com/strict/used",
+			HTTPMethod:   http.MethodGet,
+			RespHTTPCode: http.StatusOK,
+		},
+		&Mock{
+			URL:          "http://mytest.com/strict/unused",
+			HTTPMethod:   http.MethodGet,
+			RespHTTPCode: http.StatusOK,
+		},
+	)
+
+	Get("http://mytest.com/strict/used")
+
+	// As the test would do: the deferred flush runs before the cleanups
+	FlushMockups()
+
+	for _, f := range tb.cleanups {
+		f()
+	}
+
+	if len(tb.errors) != 1 {
+		t.Fatal("Expected 1 unused Mock, got", tb.errors)
+	}
+}
+
+func TestMockCallLimit(t *testing.T) {
+
+	ms := NewMockServer(t, MockCallLimit(2))
+	rb := &RequestBuilder{MockServer: ms}
+
+	mock := &Mock{URL: "http://mytest.com/limit/*", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK}
+	ms.AddMockups(mock)
+
+	for _, path := range []string{"/1", "/2", "/3"} {
+		rb.Get("http://mytest.com/limit" + path)
+	}
+
+	calls := ms.MockCalls()
+	if len(calls) != 2 || calls[0].URL != "http://mytest.com/limit/2" || calls[1].URL != "http://mytest.com/limit/3" {
+		t.Fatal("Only the latest calls should be kept", calls)
+	}
+
+	ms.AssertCalled(t, mock, 3)
+}
+
+func TestStrictMockupsOrder(t *testing.T) {
+
+	ms := NewMockServer(t)
+
+	for _, path := range []string{"/c", "/a", "/{id}", "/b"} {
+		ms.AddMockups(&Mock{URL: "http://mytest.com/order" + path, HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK})
+	}
+
+	tb := &recordingTB{TB: t}
+	ms.checkStrict(tb)
+
+	want := []string{"/a", "/b", "/c", "/{id}"}
+	if len(tb.errors) != len(want) {
+		t.Fatal("Expected 4 unused Mocks, got", tb.errors)
+	}
+
+	for i, path := range want {
+		if e := "Mock GET http://mytest.com/order" + path + " was never called"; tb.errors[i] != e {
+			t.Fatal("Unused Mocks should be sorted", tb.errors)
+		}
+	}
+}
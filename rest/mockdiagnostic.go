@@ -0,0 +1,201 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// StatusMockUnmatched is the status code of the responses to requests that
+// didn't match any Mock. It is not a standard status code, so it can't be
+// mistaken for a response of the real API. These responses have the header
+// X-Restful-Mock: unmatched as well, and the diagnostic as body.
+const StatusMockUnmatched = 599
+
+// Amount of Mocks listed in the diagnostic of an unmatched request.
+const closestMocks = 3
+
+// Longest body shown in a diagnostic.
+const maxDiagnosticBody = 200
+
+// mockDiff is how far a Mock is from matching a request.
+type mockDiff struct {
+	mock  *Mock
+	score int
+	diffs []string
+}
+
+// FailOnUnmatched fails the test as soon as a request to the global mockup
+// server doesn't match any Mock. See MockServer.FailOnUnmatched
+func FailOnUnmatched(t testing.TB) {
+	defaultMockServer.FailOnUnmatched(t)
+}
+
+// FailOnUnmatched fails the test as soon as a request doesn't match any
+// Mock, with the diagnostic. It lasts until the test finishes.
+func (s *MockServer) FailOnUnmatched(t testing.TB) {
+
+	s.mtx.Lock()
+	s.unmatchedTB = t
+	s.mtx.Unlock()
+
+	t.Cleanup(func() {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		if s.unmatchedTB == t {
+			s.unmatchedTB = nil
+		}
+	})
+}
+
+// diagnose explains why no Mock matched the request, listing the closest
+// Mocks, and what is different in each one. s.mtx must be held.
+func (s *MockServer) diagnose(req *http.Request, body []byte) string {
+
+	originalURL := req.Header.Get("X-Original-URL")
+
+	var diffs []mockDiff
+
+	for _, mocks := range s.mocks {
+		for _, m := range mocks {
+			diffs = append(diffs, s.diff(m, req, originalURL, body))
+		}
+	}
+
+	for _, m := range s.patterns {
+		diffs = append(diffs, s.diff(m, req, originalURL, body))
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		a, b := diffs[i], diffs[j]
+
+		switch {
+		case a.score != b.score:
+			return a.score < b.score
+		case a.mock.URL != b.mock.URL:
+			return a.mock.URL < b.mock.URL
+		}
+
+		return a.mock.HTTPMethod < b.mock.HTTPMethod
+	})
+
+	var diagnostic strings.Builder
+
+	fmt.Fprintf(&diagnostic, "No Mock matched %s %s\n", req.Method, originalURL)
+
+	if len(diffs) == 0 {
+		diagnostic.WriteString("There are no Mocks\n")
+		return diagnostic.String()
+	}
+
+	diagnostic.WriteString("Closest Mocks:\n")
+
+	for i, d := range diffs {
+		if i == closestMocks {
+			break
+		}
+
+		fmt.Fprintf(&diagnostic, "\t%s %s\n", d.mock.HTTPMethod, d.mock.URL)
+
+		for _, diff := range d.diffs {
+			fmt.Fprintf(&diagnostic, "\t\t%s\n", diff)
+		}
+	}
+
+	return diagnostic.String()
+}
+
+// diff compares the Mock with the request. Different methods and URLs weigh
+// more than other differences.
+func (s *MockServer) diff(m *Mock, req *http.Request, originalURL string, body []byte) mockDiff {
+
+	d := mockDiff{mock: m}
+
+	add := func(score int, format string, args ...interface{}) {
+		d.score += score
+		d.diffs = append(d.diffs, fmt.Sprintf(format, args...))
+	}
+
+	if m.HTTPMethod != req.Method {
+		add(2, "method: got %s, expected %s", req.Method, m.HTTPMethod)
+	}
+
+	reqKey, query := mockKey("", originalURL)
+
+	switch {
+	case m.pattern != nil:
+		matched := false
+
+		if reqURL, err := url.Parse(originalURL); err == nil {
+			reqURL.Fragment = ""
+			reqURL.RawFragment = ""
+			_, matched = m.pattern.match(reqURL)
+		}
+
+		if !matched {
+			add(3, "url: %s doesn't match the pattern", originalURL)
+		}
+
+		// The query is part of regular expressions
+		if m.pattern.withQuery {
+			query = m.query
+		}
+
+	default:
+		if key, _ := mockKey("", m.URL); key != reqKey {
+			add(3, "url: got %s, expected %s", strings.TrimSpace(reqKey), strings.TrimSpace(key))
+		}
+	}
+
+	if !sameQuery(m.query, query) {
+		add(1, "query: got %q, expected %q", query.Encode(), m.query.Encode())
+	}
+
+	if m.OnExhausted == SequenceFail && len(m.Responses) > 0 && m.calls >= len(m.Responses) {
+		add(1, "responses: all of them were used")
+	}
+
+	if m.RequiredState != "" {
+		if state := s.scenarioState(m.Scenario); state != m.RequiredState {
+			add(1, "scenario %s: in state %q, expected %q", m.Scenario, state, m.RequiredState)
+		}
+	}
+
+	for k, values := range m.ReqHeaders {
+		for _, v := range values {
+			if got := req.Header[textproto.CanonicalMIMEHeaderKey(k)]; !match(v, got) {
+				add(1, "header %s: got %q, expected %q", k, got, v)
+			}
+		}
+	}
+
+	for _, k := range m.present {
+		if len(req.Header[textproto.CanonicalMIMEHeaderKey(k)]) == 0 {
+			add(1, "header %s: missing", k)
+		}
+	}
+
+	if m.ReqBody != "" && !m.ReqBodyMatch.equal([]byte(m.ReqBody), body) {
+		add(1, "body: got %s, expected %s", clipBody(string(body)), clipBody(m.ReqBody))
+	}
+
+	if m.ReqBodyFunc != nil && !m.ReqBodyFunc(body) {
+		add(1, "body: rejected by ReqBodyFunc")
+	}
+
+	return d
+}
+
+func clipBody(body string) string {
+
+	if len(body) > maxDiagnosticBody {
+		body = body[:maxDiagnosticBody] + "..."
+	}
+
+	return fmt.Sprintf("%q", body)
+}
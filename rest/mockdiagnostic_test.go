@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMockupDiagnostic(t *testing.T) {
+
+	ms := NewMockServer(t)
+	rb := &RequestBuilder{MockServer: ms}
+
+	ms.AddMockups(
+		&Mock{
+			URL:          "http://mytest.com/diagnostic/users",
+			HTTPMethod:   http.MethodPost,
+			ReqHeaders:   http.Header{"X-Tenant": {"a"}},
+			ReqBody:      `{"id":0,"name":"Juan"}`,
+			RespHTTPCode: http.StatusCreated,
+		},
+		&Mock{
+			URL:          "http://mytest.com/diagnostic/users/{id}",
+			HTTPMethod:   http.MethodGet,
+			RespHTTPCode: http.StatusOK,
+		},
+		&Mock{
+			URL:          "http://mytest.com/other",
+			HTTPMethod:   http.MethodDelete,
+			RespHTTPCode: http.StatusOK,
+		},
+	)
+
+	resp := rb.Post("http://mytest.com/diagnostic/users", &User{Name: "Hernan"}, WithHeader("X-Tenant", "b"))
+
+	if resp.StatusCode != StatusMockUnmatched || resp.Header.Get("X-Restful-Mock") != "unmatched" {
+		t.Fatal("Wrong unmatched response", resp.StatusCode)
+	}
+
+	calls := ms.UnmatchedCalls()
+	if len(calls) != 1 || calls[0].Diagnostic != resp.String() {
+		t.Fatal("The diagnostic should be recorded")
+	}
+
+	lines := strings.Split(calls[0].Diagnostic, "\n")
+	expected := []string{
+		"No Mock matched POST http://mytest.com/diagnostic/users",
+		"Closest Mocks:",
+		"\tPOST http://mytest.com/diagnostic/users",
+		"\t\theader X-Tenant: got [\"b\"], expected \"a\"",
+		"\t\tbody: got \"{\\\"id\\\":0,\\\"name\\\":\\\"Hernan\\\"}\", expected \"{\\\"id\\\":0,\\\"name\\\":\\\"Juan\\\"}\"",
+		"\tGET http://mytest.com/diagnostic/users/{id}",
+		"\t\tmethod: got POST, expected GET",
+		"\t\turl: http://mytest.com/diagnostic/users doesn't match the pattern",
+		"\tDELETE http://mytest.com/other",
+	}
+
+	for i, line := range expected {
+		if i >= len(lines) || lines[i] != line {
+			t.Fatalf("Wrong diagnostic:\n%s", calls[0].Diagnostic)
+		}
+	}
+
+	tb := &recordingTB{TB: t}
+	ms.FailOnUnmatched(tb)
+
+	rb.Get("http://mytest.com/diagnostic/users/1")
+	rb.Get("http://mytest.com/diagnostic/items")
+
+	if len(tb.errors) != 1 || !strings.HasPrefix(tb.errors[0], "No Mock matched GET http://mytest.com/diagnostic/items") {
+		t.Fatal("FailOnUnmatched should fail the test", tb.errors)
+	}
+}
@@ -0,0 +1,152 @@
+package rest
+
+import (
+	"crypto/tls"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Latency returns how long the mockup server waits before answering a
+// request. It is called once per request.
+type Latency func() time.Duration
+
+// FixedLatency always waits d.
+func FixedLatency(d time.Duration) Latency {
+	return func() time.Duration {
+		return d
+	}
+}
+
+// UniformLatency waits a random duration between min and max, all of them
+// equally likely.
+func UniformLatency(min, max time.Duration) Latency {
+	return func() time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(rand.Int63n(int64(max-min)))
+	}
+}
+
+// NormalLatency waits a random duration, following a normal distribution
+// with the given mean and standard deviation. It never waits less than 0.
+func NormalLatency(mean, stdDev time.Duration) Latency {
+	return func() time.Duration {
+		if d := time.Duration(rand.NormFloat64()*float64(stdDev)) + mean; d > 0 {
+			return d
+		}
+		return 0
+	}
+}
+
+// Fault is a failure the mockup server simulates, instead of answering a
+// request with a well formed response.
+type Fault int
+
+const (
+	// NoFault answers with a well formed response.
+	NoFault Fault = iota
+
+	// FaultDropConnection closes the connection without answering.
+	FaultDropConnection
+
+	// FaultResetBody sends the headers and half of the body, and then resets
+	// the connection.
+	FaultResetBody
+
+	// FaultTruncateBody sends the headers and half of the body, and then
+	// closes the connection, although Content-Length has the full length.
+	FaultTruncateBody
+
+	// FaultStallHeaders never answers, until the client gives up or the
+	// mockup server is stopped.
+	FaultStallHeaders
+)
+
+// wait sleeps the latency of the response. It returns false if the client
+// gave up in the meantime, or the mockup server was stopped.
+func (resp *MockResponse) wait(req *http.Request, stop <-chan struct{}) bool {
+
+	if resp.Latency == nil {
+		return true
+	}
+
+	timer := time.NewTimer(resp.Latency())
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-req.Context().Done():
+		return false
+	case <-stop:
+		return false
+	}
+}
+
+// writeFault simulates the fault of the response. Stalled responses end
+// when stop is closed.
+func (resp *MockResponse) writeFault(writer http.ResponseWriter, req *http.Request, body []byte, stop <-chan struct{}) {
+
+	switch resp.Fault {
+
+	case FaultStallHeaders:
+		select {
+		case <-req.Context().Done():
+		case <-stop:
+		}
+
+	case FaultDropConnection:
+		if conn := hijack(writer); conn != nil {
+			conn.Close()
+		}
+
+	case FaultResetBody, FaultTruncateBody:
+		writer.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		writer.WriteHeader(resp.HTTPCode)
+		writer.Write(body[:len(body)/2])
+
+		if f, ok := writer.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		conn := hijack(writer)
+		if conn == nil {
+			return
+		}
+
+		// Discard unsent data, and send a RST instead of a FIN. TLS
+		// connections are closed without close_notify as well
+		if resp.Fault == FaultResetBody {
+			if tlsConn, ok := conn.(*tls.Conn); ok {
+				conn = tlsConn.NetConn()
+			}
+
+			if tcp, ok := conn.(*net.TCPConn); ok {
+				tcp.SetLinger(0)
+			}
+		}
+
+		conn.Close()
+	}
+}
+
+func hijack(writer http.ResponseWriter) net.Conn {
+
+	hj, ok := writer.(http.Hijacker)
+	if !ok {
+		return nil
+	}
+
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		return nil
+	}
+
+	buf.Flush()
+
+	return conn
+}
@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestMockupLatency(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	myURL := "http://mytest.com/latency"
+
+	AddMockups(&Mock{
+		URL:          myURL,
+		HTTPMethod:   http.MethodGet,
+		Latency:      FixedLatency(50 * time.Millisecond),
+		RespHTTPCode: http.StatusOK,
+	})
+
+	if v := Get(myURL, WithTimeout(10*time.Millisecond)); v.Err == nil {
+		t.Fatal("Request should have timed out")
+	}
+
+	start := time.Now()
+
+	if v := Get(myURL); v.Err != nil || time.Since(start) < 50*time.Millisecond {
+		t.Fatal("Response should have been delayed")
+	}
+}
+
+func TestLatencyDistributions(t *testing.T) {
+
+	uniform := UniformLatency(10*time.Millisecond, 20*time.Millisecond)
+	normal := NormalLatency(10*time.Millisecond, 50*time.Millisecond)
+
+	for i := 0; i < 1000; i++ {
+		if d := uniform(); d < 10*time.Millisecond || d >= 20*time.Millisecond {
+			t.Fatal("Uniform latency out of range: " + d.String())
+		}
+
+		if d := normal(); d < 0 {
+			t.Fatal("Normal latency is negative: " + d.String())
+		}
+	}
+}
+
+func TestMockupFaults(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	faults := map[string]Fault{
+		"drop":     FaultDropConnection,
+		"reset":    FaultResetBody,
+		"truncate": FaultTruncateBody,
+		"stall":    FaultStallHeaders,
+	}
+
+	for name, fault := range faults {
+		AddMockups(&Mock{
+			URL:          "http://mytest.com/fault/" + name,
+			HTTPMethod:   http.MethodGet,
+			Fault:        fault,
+			RespHTTPCode: http.StatusOK,
+			RespBody:     strings.Repeat("body", 1024),
+		})
+	}
+
+	for name := range faults {
+		if v := Get("http://mytest.com/fault/"+name, WithTimeout(100*time.Millisecond)); v.Err == nil {
+			t.Fatal(name + ": request should have failed")
+		}
+	}
+}
+
+func TestMockupSequenceFaults(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	myURL := "http://mytest.com/fault/sequence"
+
+	AddMockups(&Mock{
+		URL:        myURL,
+		HTTPMethod: http.MethodGet,
+		Responses: []MockResponse{
+			{Fault: FaultDropConnection},
+			{HTTPCode: http.StatusServiceUnavailable, Latency: FixedLatency(time.Second)},
+			{HTTPCode: http.StatusOK, Body: "ok"},
+		},
+	})
+
+	if v := Get(myURL); v.Err == nil {
+		t.Fatal("Connection should have been dropped")
+	}
+
+	if v := Get(myURL, WithTimeout(10*time.Millisecond)); v.Err == nil {
+		t.Fatal("Request should have timed out")
+	}
+
+	if v := Get(myURL); v.Err != nil || v.String() != "ok" {
+		t.Fatal("Request should have succeeded")
+	}
+}
+
+func TestMockupResetBody(t *testing.T) {
+
+	for name, opts := range map[string][]MockServerOption{"http": nil, "https": {MockTLS()}} {
+
+		ms := NewMockServer(t, opts...)
+		ms.AddMockups(&Mock{
+			URL:          name + "://mytest.com/fault/reset",
+			HTTPMethod:   http.MethodGet,
+			Fault:        FaultResetBody,
+			RespHTTPCode: http.StatusOK,
+			RespBody:     strings.Repeat("body", 1024),
+		})
+
+		rb := &RequestBuilder{MockServer: ms}
+
+		if v := rb.Get(name + "://mytest.com/fault/reset"); !errors.Is(v.Err, syscall.ECONNRESET) {
+			t.Fatal(name+": the connection should be reset", v.Err)
+		}
+	}
+}
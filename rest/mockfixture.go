@@ -0,0 +1,647 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FixtureError is an error in a fixture file. Line is 0 if unknown.
+type FixtureError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *FixtureError) Error() string {
+
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+func (e *FixtureError) Unwrap() error {
+	return e.Err
+}
+
+// mockFixture is a Mock, as written in a fixture file.
+type mockFixture struct {
+	URL           string            `json:"url"`
+	HTTPMethod    string            `json:"http_method"`
+	ReqHeaders    fixtureHeader     `json:"req_headers,omitempty"`
+	ReqBody       *fixtureBody      `json:"req_body,omitempty"`
+	ReqBodyMatch  string            `json:"req_body_match,omitempty"`
+	RespHTTPCode  int               `json:"resp_http_code,omitempty"`
+	RespHeaders   fixtureHeader     `json:"resp_headers,omitempty"`
+	RespBody      *fixtureBody      `json:"resp_body,omitempty"`
+	RespBodyFile  string            `json:"resp_body_file,omitempty"`
+	Responses     []fixtureResponse `json:"responses,omitempty"`
+	OnExhausted   string            `json:"on_exhausted,omitempty"`
+	Scenario      string            `json:"scenario,omitempty"`
+	RequiredState string            `json:"required_state,omitempty"`
+	NewState      string            `json:"new_state,omitempty"`
+	Latency       string            `json:"latency,omitempty"`
+	Fault         string            `json:"fault,omitempty"`
+}
+
+type fixtureResponse struct {
+	HTTPCode int           `json:"http_code,omitempty"`
+	Headers  fixtureHeader `json:"headers,omitempty"`
+	Body     *fixtureBody  `json:"body,omitempty"`
+	BodyFile string        `json:"body_file,omitempty"`
+	Latency  string        `json:"latency,omitempty"`
+	Fault    string        `json:"fault,omitempty"`
+}
+
+// fixtureHeader values could be a string, or a list of them.
+type fixtureHeader map[string]fixtureValues
+
+type fixtureValues []string
+
+func (v *fixtureValues) UnmarshalJSON(data []byte) error {
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		list = []interface{}{value}
+	}
+
+	for _, item := range list {
+		switch item.(type) {
+		case nil, []interface{}, map[string]interface{}:
+			return errors.New("header values must be strings, or lists of them")
+		}
+
+		*v = append(*v, fmt.Sprint(item))
+	}
+
+	return nil
+}
+
+func (h fixtureHeader) header() http.Header {
+
+	if h == nil {
+		return nil
+	}
+
+	header := make(http.Header)
+	for k, values := range h {
+		for _, v := range values {
+			header.Add(k, v)
+		}
+	}
+
+	return header
+}
+
+// fixtureBody is a string, or any other JSON value, which is kept as JSON.
+type fixtureBody struct {
+	body string
+	json bool
+}
+
+// newFixtureBody keeps JSON bodies as JSON.
+func newFixtureBody(body []byte, isJSON bool) *fixtureBody {
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if isJSON && json.Compact(&buf, body) == nil {
+		return &fixtureBody{body: buf.String(), json: true}
+	}
+
+	return &fixtureBody{body: string(body)}
+}
+
+func (b *fixtureBody) String() string {
+
+	if b == nil {
+		return ""
+	}
+
+	return b.body
+}
+
+func (b *fixtureBody) isJSON() bool {
+	return b != nil && b.json
+}
+
+func (b *fixtureBody) UnmarshalJSON(data []byte) error {
+
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		return json.Unmarshal(data, &b.body)
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return err
+	}
+
+	b.body, b.json = buf.String(), true
+
+	return nil
+}
+
+func (b *fixtureBody) MarshalJSON() ([]byte, error) {
+
+	if b.json {
+		return []byte(b.body), nil
+	}
+
+	return json.Marshal(b.body)
+}
+
+var fixtureBodyMatches = map[string]BodyMatch{
+	"":      BodyExact,
+	"exact": BodyExact,
+	"json":  BodyJSON,
+}
+
+var fixtureSequenceModes = map[string]SequenceMode{
+	"":            SequenceRepeatLast,
+	"repeat_last": SequenceRepeatLast,
+	"cycle":       SequenceCycle,
+	"fail":        SequenceFail,
+}
+
+var fixtureFaults = map[string]Fault{
+	"":                NoFault,
+	"none":            NoFault,
+	"drop_connection": FaultDropConnection,
+	"reset_body":      FaultResetBody,
+	"truncate_body":   FaultTruncateBody,
+	"stall_headers":   FaultStallHeaders,
+}
+
+// LoadMockups reads the Mocks of fixture files, or directory trees of them,
+// and adds them to the global mockup server. See ReadMockups.
+func LoadMockups(paths ...string) error {
+	return defaultMockServer.LoadMockups(paths...)
+}
+
+// LoadMockups reads the Mocks of fixture files, or directory trees of them,
+// and adds them to the mockup server. See ReadMockups.
+func (s *MockServer) LoadMockups(paths ...string) error {
+
+	mocks, err := ReadMockups(paths...)
+	if err != nil {
+		return err
+	}
+
+	return s.AddMockups(mocks...)
+}
+
+// ReadMockups reads the Mocks of fixture files, or directory trees of them.
+// Fixture files are JSON (.json) or YAML (.yaml, .yml), and they hold a Mock,
+// or a list of them. Their fields are the ones of Mock, in snake case:
+//
+//	url: http://api.com/users/{id}
+//	http_method: GET
+//	req_headers:
+//	  Accept: application/json
+//	resp_http_code: 200
+//	resp_body_file: bodies/user.json
+//
+// YAML files may hold many documents, separated by ---. Anchors, aliases and
+// tags are not supported.
+//
+// Response bodies could be kept apart, in files referenced by resp_body_file
+// (body_file in responses), relative to the fixture file. Files in
+// directory trees referenced this way are not read as fixtures.
+//
+// Bodies that aren't strings are JSON. When req_body is not a string, it is
+// compared as JSON. Latencies are durations, like 150ms. Enumerations are in
+// snake case too, like cycle or drop_connection. resp_http_code defaults
+// to 200.
+//
+// Errors in fixture files are *FixtureError, with the file and line.
+func ReadMockups(paths ...string) ([]*Mock, error) {
+
+	type fixtureFile struct {
+		path   string
+		walked bool
+		mocks  []*Mock
+		err    error
+	}
+
+	var files []*fixtureFile
+
+	for _, path := range paths {
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, &fixtureFile{path: path})
+			continue
+		}
+
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && isFixtureFile(file) {
+				files = append(files, &fixtureFile{path: file, walked: true})
+			}
+			return err
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bodyFiles := make(map[string]bool)
+
+	for _, f := range files {
+		var bodies []string
+		f.mocks, bodies, f.err = readFixtureFile(f.path)
+
+		for _, body := range bodies {
+			bodyFiles[body] = true
+		}
+	}
+
+	var mocks []*Mock
+
+	for _, f := range files {
+
+		if abs, err := filepath.Abs(f.path); err == nil && f.walked && bodyFiles[abs] {
+			continue
+		}
+
+		if f.err != nil {
+			return nil, f.err
+		}
+
+		mocks = append(mocks, f.mocks...)
+	}
+
+	return mocks, nil
+}
+
+func isFixtureFile(file string) bool {
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+
+	return false
+}
+
+func isYAMLFile(file string) bool {
+
+	ext := strings.ToLower(filepath.Ext(file))
+
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// ParseMockups parses the Mocks of a fixture, as ReadMockups does with
+// fixture files. Fixtures starting with { or [ are JSON, and YAML otherwise.
+// Body files are relative to the working directory.
+func ParseMockups(data []byte) ([]*Mock, error) {
+
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	yaml := !bytes.HasPrefix(trimmed, []byte("{")) && !bytes.HasPrefix(trimmed, []byte("["))
+
+	mocks, _, err := parseFixture("fixture", data, yaml, ".")
+
+	return mocks, err
+}
+
+// readFixtureFile reads the Mocks of a fixture file. It returns the absolute
+// paths of the body files its Mocks reference as well.
+func readFixtureFile(file string) ([]*Mock, []string, error) {
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return parseFixture(file, data, isYAMLFile(file), filepath.Dir(file))
+}
+
+// parseFixture parses the Mocks of a fixture, whose body files are relative
+// to dir. It returns the absolute paths of the body files as well.
+func parseFixture(file string, data []byte, yaml bool, dir string) ([]*Mock, []string, error) {
+
+	items, err := splitFixture(file, data, yaml)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var mocks []*Mock
+	var bodies []string
+
+	for _, it := range items {
+
+		var fixture mockFixture
+
+		dec := json.NewDecoder(bytes.NewReader(it.data))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(&fixture); err != nil {
+			return nil, nil, &FixtureError{file, it.errorLine(err), err}
+		}
+
+		m, refs, err := fixture.mock(dir)
+		if err != nil {
+			return nil, nil, &FixtureError{file, it.line, err}
+		}
+
+		mocks = append(mocks, m)
+		bodies = append(bodies, refs...)
+	}
+
+	return mocks, bodies, nil
+}
+
+// fixtureItem is an item of a fixture: a Mock, or an item of a MockResource,
+// as JSON, and the line where it starts.
+type fixtureItem struct {
+	line int
+	data []byte
+
+	// Lines of the fields of YAML items, which errors don't have offsets of
+	keys map[string]int
+}
+
+// splitFixture splits a JSON or YAML fixture, which holds an item or a list
+// of them. YAML documents with no content are skipped.
+func splitFixture(file string, data []byte, yaml bool) ([]fixtureItem, error) {
+
+	var items []fixtureItem
+
+	if !yaml {
+		offsets, raws, err := splitJSONFixture(data)
+		if err != nil {
+			return nil, &FixtureError{file, jsonErrorLine(data, 0, err), err}
+		}
+
+		for i, raw := range raws {
+			items = append(items, fixtureItem{line: lineAt(data, offsets[i]), data: raw})
+		}
+
+		return items, nil
+	}
+
+	values, err := parseYAML(data)
+	if err != nil {
+		yerr := err.(*yamlError)
+		return nil, &FixtureError{file, yerr.line, yerr}
+	}
+
+	for _, v := range values {
+		if v.value == nil {
+			continue
+		}
+
+		data, err := json.Marshal(v.value)
+		if err != nil {
+			return nil, &FixtureError{file, v.line, err}
+		}
+
+		items = append(items, fixtureItem{v.line, data, v.keys})
+	}
+
+	return items, nil
+}
+
+// errorLine returns the line of an error decoding the item: the line of the
+// field for YAML items, and of the offset for JSON items.
+func (it *fixtureItem) errorLine(err error) int {
+
+	var typeErr *json.UnmarshalTypeError
+
+	if errors.As(err, &typeErr) {
+		field := strings.SplitN(typeErr.Field, ".", 2)[0]
+		if line, ok := it.keys[field]; ok {
+			return line
+		}
+	}
+
+	return jsonErrorLine(it.data, it.line, err)
+}
+
+// splitJSONFixture splits a JSON fixture, which holds a Mock or a list of
+// them, and returns the offset where each one starts.
+func splitJSONFixture(data []byte) ([]int64, []json.RawMessage, error) {
+
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+
+	if !bytes.HasPrefix(trimmed, []byte("[")) {
+		var raw json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, nil, err
+		}
+
+		return []int64{int64(len(data) - len(trimmed))}, []json.RawMessage{raw}, nil
+	}
+
+	var offsets []int64
+	var raws []json.RawMessage
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+
+	for dec.More() {
+		offset := dec.InputOffset()
+		for offset < int64(len(data)) && bytes.IndexByte([]byte(", \t\r\n"), data[offset]) >= 0 {
+			offset++
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+
+		offsets = append(offsets, offset)
+		raws = append(raws, raw)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+
+	return offsets, raws, nil
+}
+
+// jsonErrorLine returns the line of a JSON error, if it has an offset.
+// Otherwise it returns the line where the data starts.
+func jsonErrorLine(data []byte, first int, err error) int {
+
+	var offset int64 = -1
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+
+	if first == 0 {
+		first = 1
+	}
+
+	if offset < 0 || offset > int64(len(data)) {
+		return first
+	}
+
+	return first + bytes.Count(data[:offset], []byte("\n"))
+}
+
+func lineAt(data []byte, offset int64) int {
+	return 1 + bytes.Count(data[:offset], []byte("\n"))
+}
+
+// mock converts the fixture to a Mock. Body files are relative to dir.
+func (f *mockFixture) mock(dir string) (*Mock, []string, error) {
+
+	if f.URL == "" || f.HTTPMethod == "" {
+		return nil, nil, errors.New("url and http_method are required")
+	}
+
+	if isMockPattern(f.URL) {
+		if _, err := compileMockPattern(f.URL); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	m := &Mock{
+		URL:           f.URL,
+		HTTPMethod:    strings.ToUpper(f.HTTPMethod),
+		ReqHeaders:    f.ReqHeaders.header(),
+		ReqBody:       f.ReqBody.String(),
+		RespHTTPCode:  f.RespHTTPCode,
+		RespHeaders:   f.RespHeaders.header(),
+		RespBody:      f.RespBody.String(),
+		Scenario:      f.Scenario,
+		RequiredState: f.RequiredState,
+		NewState:      f.NewState,
+	}
+
+	var ok bool
+	var err error
+	var bodies []string
+
+	if m.ReqBodyMatch, ok = fixtureBodyMatches[f.ReqBodyMatch]; !ok {
+		return nil, nil, fmt.Errorf("unknown req_body_match %q", f.ReqBodyMatch)
+	}
+
+	if f.ReqBodyMatch == "" && f.ReqBody.isJSON() {
+		m.ReqBodyMatch = BodyJSON
+	}
+
+	if m.OnExhausted, ok = fixtureSequenceModes[f.OnExhausted]; !ok {
+		return nil, nil, fmt.Errorf("unknown on_exhausted %q", f.OnExhausted)
+	}
+
+	if m.Latency, m.Fault, err = fixtureFailure(f.Latency, f.Fault); err != nil {
+		return nil, nil, err
+	}
+
+	if m.RespHTTPCode == 0 {
+		m.RespHTTPCode = http.StatusOK
+	}
+
+	if f.RespBodyFile != "" {
+		file := fixtureBodyFile(dir, f.RespBodyFile)
+		if m.RespBody, err = readBodyFile(file); err != nil {
+			return nil, nil, err
+		}
+		bodies = append(bodies, file)
+	}
+
+	for _, r := range f.Responses {
+
+		resp := MockResponse{HTTPCode: r.HTTPCode, Headers: r.Headers.header(), Body: r.Body.String()}
+
+		if resp.Latency, resp.Fault, err = fixtureFailure(r.Latency, r.Fault); err != nil {
+			return nil, nil, err
+		}
+
+		if resp.HTTPCode == 0 {
+			resp.HTTPCode = http.StatusOK
+		}
+
+		if r.BodyFile != "" {
+			file := fixtureBodyFile(dir, r.BodyFile)
+			if resp.Body, err = readBodyFile(file); err != nil {
+				return nil, nil, err
+			}
+			bodies = append(bodies, file)
+		}
+
+		m.Responses = append(m.Responses, resp)
+	}
+
+	return m, bodies, nil
+}
+
+func fixtureFailure(latency string, fault string) (Latency, Fault, error) {
+
+	f, ok := fixtureFaults[fault]
+	if !ok {
+		return nil, NoFault, fmt.Errorf("unknown fault %q", fault)
+	}
+
+	if latency == "" {
+		return nil, f, nil
+	}
+
+	d, err := time.ParseDuration(latency)
+	if err != nil {
+		return nil, NoFault, err
+	}
+
+	return FixedLatency(d), f, nil
+}
+
+// fixtureBodyFile returns the absolute path of a body file.
+func fixtureBodyFile(dir string, file string) string {
+
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+
+	return file
+}
+
+func readBodyFile(file string) (string, error) {
+
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
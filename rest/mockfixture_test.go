@@ -0,0 +1,277 @@
+package rest
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFixtures(t *testing.T, files map[string]string) string {
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoadMockups(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	dir := writeFixtures(t, map[string]string{
+		"users.json": `[
+			{
+				"url": "http://mytest.com/fixture/users/{id}",
+				"http_method": "GET",
+				"req_headers": {"Accept": ["application/json"]},
+				"resp_http_code": 200,
+				"resp_headers": {"X-Fixture": "yes"},
+				"resp_body_file": "bodies/user.json"
+			},
+			{
+				"url": "http://mytest.com/fixture/users",
+				"http_method": "post",
+				"req_body": {"id": 0, "name": "Hernan"},
+				"resp_http_code": 201,
+				"resp_body": "created\n"
+			}
+		]`,
+		"bodies/user.json": `{"id": {id}, "name": "Hernan"}`,
+		"orders/orders.yaml": `
+# Orders
+- url: http://mytest.com/fixture/orders/{id}
+  http_method: GET
+  req_headers:
+    Accept: [application/json]
+  resp_headers: {X-Fixture: "yes"}
+  resp_body:
+    id: 1
+    items: [book, pen]
+---
+url: http://mytest.com/fixture/orders
+http_method: post
+resp_http_code: 201
+resp_body: |
+  created
+`,
+		"sequence/retry.json": `[{
+			"url": "http://mytest.com/fixture/retry",
+			"http_method": "GET",
+			"responses": [
+				{"http_code": 503, "headers": {"Retry-After": 1}},
+				{"body": {"ok": true}}
+			]
+		}]`,
+	})
+
+	if err := LoadMockups(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := Get("http://mytest.com/fixture/users/7")
+	if resp.StatusCode != http.StatusOK || resp.String() != `{"id": 7, "name": "Hernan"}` ||
+		resp.Header.Get("X-Fixture") != "yes" {
+		t.Fatal("Wrong response", resp.StatusCode, resp.String())
+	}
+
+	resp = Post("http://mytest.com/fixture/users", &User{Name: "Hernan"})
+	if resp.StatusCode != http.StatusCreated || resp.String() != "created\n" {
+		t.Fatal("Wrong response", resp.StatusCode, resp.String())
+	}
+
+	resp = Get("http://mytest.com/fixture/orders/1", WithHeader("Accept", "application/json"))
+	if resp.StatusCode != http.StatusOK || resp.String() != `{"id":1,"items":["book","pen"]}` {
+		t.Fatal("Wrong response", resp.StatusCode, resp.String())
+	}
+
+	resp = Post("http://mytest.com/fixture/orders", nil)
+	if resp.StatusCode != http.StatusCreated || resp.String() != "created\n" {
+		t.Fatal("Wrong response", resp.StatusCode, resp.String())
+	}
+
+	resp = Get("http://mytest.com/fixture/retry")
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "1" {
+		t.Fatal("Wrong response", resp.StatusCode)
+	}
+
+	resp = Get("http://mytest.com/fixture/retry")
+	if resp.StatusCode != http.StatusOK || resp.String() != `{"ok":true}` {
+		t.Fatal("Wrong response", resp.StatusCode, resp.String())
+	}
+}
+
+func TestReadMockupsErrors(t *testing.T) {
+
+	tests := map[string]int{
+		"syntax.json":  3,
+		"type.json":    4,
+		"unknown.json": 3,
+		"fault.json":   1,
+		"pattern.json": 3,
+		"syntax.yaml":  5,
+		"type.yaml":    7,
+		"unknown.yaml": 5,
+		"pattern.yml":  4,
+		"anchor.yaml":  4,
+	}
+
+	dir := writeFixtures(t, map[string]string{
+		"syntax.json":  "[\n  {\"url\": \"http://a.com\", \"http_method\": \"GET\"},\n  {\"url\": }\n]",
+		"type.json":    "[\n  {\"url\": \"http://a.com\", \"http_method\": \"GET\"},\n  {\"url\": \"http://a.com\",\n   \"http_method\": 1}\n]",
+		"unknown.json": "[\n  {\"url\": \"http://a.com\", \"http_method\": \"GET\"},\n  {\"url\": \"http://a.com\", \"http_methd\": \"GET\"}\n]",
+		"fault.json":   `{"url": "http://a.com", "http_method": "GET", "fault": "crash"}`,
+		"pattern.json": "[\n  {\"url\": \"http://a.com\", \"http_method\": \"GET\"},\n  {\"url\": \"^http://a.com/(\", \"http_method\": \"GET\"}\n]",
+		"syntax.yaml": `url: http://a.com
+http_method: GET
+resp_headers:
+    X-A: 1
+  X-B: 2
+`,
+		"type.yaml": `- url: http://a.com
+  http_method: GET
+
+# The code is not a number
+- url: http://a.com
+  http_method: GET
+  resp_http_code: ok
+`,
+		"unknown.yaml": `- url: http://a.com
+  http_method: GET
+
+# Typo
+- url: http://a.com
+  http_methd: GET
+`,
+		"pattern.yml": "url: http://a.com\nhttp_method: GET\n---\nurl: ^http://a.com/(\nhttp_method: GET\n",
+		"anchor.yaml": "---\n- url: http://a.com\n  http_method: GET\n  latency: &slow 1s\n",
+	})
+
+	for name, line := range tests {
+
+		_, err := ReadMockups(filepath.Join(dir, name))
+
+		var ferr *FixtureError
+		if !errors.As(err, &ferr) {
+			t.Fatalf("%s: expected a FixtureError, got %v", name, err)
+		}
+
+		if ferr.Line != line || ferr.File != filepath.Join(dir, name) {
+			t.Fatalf("%s: expected line %d, got %v", name, line, err)
+		}
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+
+	items, err := parseYAML([]byte(`%YAML 1.2
+---
+a: 1
+b: "two # not a comment" # a comment
+c:
+- x
+- 'it''s'
+d: [1, 2.5,
+  {e: true}, null]
+f: >
+  folded
+  text
+
+  more
+g: |-
+  literal
+    text
+h:
+  - i: 1
+    j:
+      k: ~
+  - |
+    block item
+i: a plain scalar
+  on two lines
+---
+- 1
+- "two\tlines,
+  \u00e9"
+`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := map[string]int{"a": 3, "b": 4, "c": 5, "d": 8, "f": 10, "g": 15, "h": 18, "i": 24}
+
+	expected := []yamlItem{
+		{3, map[string]interface{}{
+			"a": 1.0,
+			"b": "two # not a comment",
+			"c": []interface{}{"x", "it's"},
+			"d": []interface{}{1.0, 2.5, map[string]interface{}{"e": true}, nil},
+			"f": "folded text\nmore\n",
+			"g": "literal\n  text",
+			"h": []interface{}{map[string]interface{}{"i": 1.0, "j": map[string]interface{}{"k": nil}}, "block item\n"},
+			"i": "a plain scalar on two lines",
+		}, keys},
+		{27, 1.0, nil},
+		{28, "two\tlines, \u00e9", nil},
+	}
+
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatalf("Got %#v", items)
+	}
+
+	errs := map[string]int{
+		"a: 1\n\tb: 2\n":          2,
+		"a: 1\na: 2\n":            2,
+		"a: b: c\n":               1,
+		"a: 1\nb: \"open\nc: 2\n": 2,
+		"a: [1, 2\n":              1,
+		"- a: 1\n- &anchor b\n":   2,
+		"a:\n  b: *alias\n":       2,
+		"a: !!str 1\n":            1,
+		"a:\n  <<: {b: 1}\n":      2,
+		"a: 1\n  b: 2\n":          2,
+		"--- a\n":                 1,
+		"a: \"\\q\"\n":            1,
+	}
+
+	for doc, line := range errs {
+		_, err := parseYAML([]byte(doc))
+
+		if yerr, ok := err.(*yamlError); !ok || yerr.line != line {
+			t.Fatalf("%q: expected an error at line %d, got %v", doc, line, err)
+		}
+	}
+}
+
+func TestParseMockups(t *testing.T) {
+
+	mocks, err := ParseMockups([]byte("url: http://mytest.com/parse\nhttp_method: GET\nresp_body: yaml\n"))
+	if err != nil || len(mocks) != 1 || mocks[0].RespBody != "yaml" {
+		t.Fatal("Wrong YAML mocks", err)
+	}
+
+	mocks, err = ParseMockups([]byte(`[{"url": "http://mytest.com/parse", "http_method": "POST", "resp_http_code": 201}]`))
+	if err != nil || len(mocks) != 1 || mocks[0].RespHTTPCode != http.StatusCreated {
+		t.Fatal("Wrong JSON mocks", err)
+	}
+
+	if _, err = ParseMockups([]byte(`{"url": 1}`)); err == nil {
+		t.Fatal("Wrong fixtures should fail")
+	}
+}
@@ -0,0 +1,33 @@
+package rest
+
+import (
+	"log"
+	"net"
+)
+
+// MockListener serves on the listener, instead of a random local port, so
+// the server could be reached from outside the tests. It is closed with the
+// server.
+func MockListener(l net.Listener) MockServerOption {
+	return mockOptionFunc(func(s *MockServer) {
+		s.listener = l
+	})
+}
+
+// MockBaseURL matches the requests sent straight to the server, rather than
+// through a RequestBuilder, as if they were sent to the base URL. So the
+// same Mocks answer both. Otherwise, their URL is just the path, and they
+// match Mocks whose URL is a path, like /users/{id}.
+func MockBaseURL(base string) MockServerOption {
+	return mockOptionFunc(func(s *MockServer) {
+		s.directBase = base
+	})
+}
+
+// MockLog logs every request, with the Mock that matched it, or with the
+// diagnostic if none did.
+func MockLog(logger *log.Logger) MockServerOption {
+	return mockOptionFunc(func(s *MockServer) {
+		s.logger = logger
+	})
+}
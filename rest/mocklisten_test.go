@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestMockupDirectRequests(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+
+	ms := NewMockServer(t, MockListener(l), MockLog(log.New(&logs, "", 0)))
+	ms.AddMockups(&Mock{
+		URL:          "/users/{id}",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     `{"id":{id}}`,
+	})
+
+	if ms.URL() != "http://"+l.Addr().String() {
+		t.Fatal("The server should listen on the listener", ms.URL())
+	}
+
+	resp, err := http.Get(ms.URL() + "/users/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || string(body) != `{"id":1}` {
+		t.Fatal("Wrong response", resp.StatusCode, string(body))
+	}
+
+	if resp, err = http.Get(ms.URL() + "/groups/1"); err != nil || resp.StatusCode != StatusMockUnmatched {
+		t.Fatal("The request should not match", err)
+	}
+	resp.Body.Close()
+
+	if logs.String() != "GET /users/1 matched GET /users/{id}\n"+
+		"No Mock matched GET /groups/1\nClosest Mocks:\n\tGET /users/{id}\n\t\turl: /groups/1 doesn't match the pattern\n" {
+		t.Fatalf("Wrong logs %q", logs.String())
+	}
+}
+
+func TestMockupBaseURL(t *testing.T) {
+
+	ms := NewMockServer(t, MockBaseURL("http://mytest.com"))
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/users/1",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "direct",
+	})
+
+	resp, err := http.Get(ms.URL() + "/users/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if body, _ := ioutil.ReadAll(resp.Body); resp.StatusCode != http.StatusOK || string(body) != "direct" {
+		t.Fatal("Direct requests should match as sent to the base URL", resp.StatusCode)
+	}
+
+	// Requests of RequestBuilders keep their URL
+	rb := &RequestBuilder{MockServer: ms}
+	if resp := rb.Get("http://mytest.com/users/1"); resp.String() != "direct" {
+		t.Fatal("Wrong response", resp.String())
+	}
+}
@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// mockPattern is a compiled Mock URL pattern.
+type mockPattern struct {
+	re *regexp.Regexp
+
+	// Names of the variables captured by the groups of re.
+	// Unnamed groups have an empty name.
+	vars []string
+
+	// Regular expressions are matched against the whole URL, query included.
+	// Templates and globs are matched against the URL without query, and the
+	// query is matched apart, as with exact URLs.
+	withQuery bool
+}
+
+// isMockPattern tells if a Mock URL is a pattern, instead of an exact URL.
+// Patterns could be:
+//
+// Regular expressions, starting with ^, matched against the whole URL:
+//
+//	^http://api\.com/users/(?P<id>\d+)\?.*$
+//
+// Path templates, capturing one path segment with {name}, or many with
+// {+name}:
+//
+//	http://api.com/users/{id}/items/{+path}
+//
+// Globs, where * matches anything but a slash, and ** matches anything:
+//
+//	http://api.com/users/*/items/**
+//
+// Only the URL before the query is looked at, so exact URLs could have
+// queries like ?fields={id,name} or ?q=*.
+func isMockPattern(mockURL string) bool {
+
+	if strings.HasPrefix(mockURL, "^") {
+		return true
+	}
+
+	if i := strings.Index(mockURL, "?"); i >= 0 {
+		mockURL = mockURL[:i]
+	}
+
+	return strings.ContainsAny(mockURL, "{*")
+}
+
+func compileMockPattern(mockURL string) (*mockPattern, error) {
+
+	if strings.HasPrefix(mockURL, "^") {
+		re, err := regexp.Compile(mockURL)
+		if err != nil {
+			return nil, err
+		}
+
+		return &mockPattern{re: re, vars: re.SubexpNames()[1:], withQuery: true}, nil
+	}
+
+	if i := strings.Index(mockURL, "?"); i >= 0 {
+		mockURL = mockURL[:i]
+	}
+
+	var expr strings.Builder
+	var vars []string
+
+	expr.WriteString("^")
+
+	for len(mockURL) > 0 {
+
+		switch {
+		case strings.HasPrefix(mockURL, "**"):
+			expr.WriteString("(.*)")
+			vars = append(vars, "")
+			mockURL = mockURL[2:]
+
+		case mockURL[0] == '*':
+			expr.WriteString("([^/]*)")
+			vars = append(vars, "")
+			mockURL = mockURL[1:]
+
+		case mockURL[0] == '{' && strings.Contains(mockURL, "}"):
+			end := strings.Index(mockURL, "}")
+			name := mockURL[1:end]
+
+			if strings.HasPrefix(name, "+") {
+				expr.WriteString("(.+)")
+				name = name[1:]
+			} else {
+				expr.WriteString("([^/]+)")
+			}
+
+			vars = append(vars, name)
+			mockURL = mockURL[end+1:]
+
+		default:
+			end := strings.IndexAny(mockURL[1:], "{*") + 1
+			if end == 0 {
+				end = len(mockURL)
+			}
+
+			expr.WriteString(regexp.QuoteMeta(mockURL[:end]))
+			mockURL = mockURL[end:]
+		}
+	}
+
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &mockPattern{re: re, vars: vars}, nil
+}
+
+// match tells if the URL matches the pattern, and returns the captured
+// variables. The URL must not have a fragment.
+func (p *mockPattern) match(reqURL *url.URL) (map[string]string, bool) {
+
+	target := *reqURL
+	if !p.withQuery {
+		target.RawQuery = ""
+		target.ForceQuery = false
+	}
+
+	groups := p.re.FindStringSubmatch(target.String())
+	if groups == nil {
+		return nil, false
+	}
+
+	vars := make(map[string]string)
+
+	for i, name := range p.vars {
+		if name != "" {
+			vars[name] = groups[i+1]
+		}
+	}
+
+	return vars, true
+}
+
+// expandMockVars replaces every {name} in s with the value of the captured
+// variable, if any.
+func expandMockVars(s string, vars map[string]string) string {
+
+	if len(vars) == 0 || !strings.Contains(s, "{") {
+		return s
+	}
+
+	oldnew := make([]string, 0, 2*len(vars))
+	for name, value := range vars {
+		oldnew = append(oldnew, "{"+name+"}", value)
+	}
+
+	return strings.NewReplacer(oldnew...).Replace(s)
+}
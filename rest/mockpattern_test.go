@@ -0,0 +1,159 @@
+package rest
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMockupTemplate(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	AddMockups(&Mock{
+		URL:          "http://mytest.com/users/{id}/files/{+path}",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespHeaders:  http.Header{"Location": {"/users/{id}"}},
+		RespBody:     `{"id":{id},"path":"{path}"}`,
+	})
+
+	v := Get("http://mytest.com/users/12/files/a/b.txt")
+
+	if v.String() != `{"id":12,"path":"a/b.txt"}` {
+		t.Fatal("Template variables were not replaced: " + v.String())
+	}
+
+	if v.Header.Get("Location") != "/users/12" {
+		t.Fatal("Template variables were not replaced in headers: " + v.Header.Get("Location"))
+	}
+
+	if v := Get("http://mytest.com/users/12/files"); v.StatusCode != StatusMockUnmatched {
+		t.Fatal("Incomplete URL should not match")
+	}
+}
+
+func TestMockupGlob(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	AddMockups(
+		&Mock{
+			URL:          "http://mytest.com/glob/*",
+			HTTPMethod:   http.MethodGet,
+			RespHTTPCode: http.StatusOK,
+			RespBody:     "one",
+		},
+		&Mock{
+			URL:          "http://mytest.com/glob/*/**",
+			HTTPMethod:   http.MethodGet,
+			RespHTTPCode: http.StatusOK,
+			RespBody:     "many",
+		},
+	)
+
+	if v := Get("http://mytest.com/glob/a"); v.String() != "one" {
+		t.Fatal("* should match one segment: " + v.String())
+	}
+
+	if v := Get("http://mytest.com/glob/a/b/c"); v.String() != "many" {
+		t.Fatal("** should match many segments: " + v.String())
+	}
+}
+
+func TestMockupRegexp(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	AddMockups(&Mock{
+		URL:          `^http://mytest\.com/regexp/(?P<id>\d+)\?lang=(?P<lang>\w+)$`,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "{id} in {lang}",
+	})
+
+	if v := Get("http://mytest.com/regexp/7?lang=es"); v.String() != "7 in es" {
+		t.Fatal("Regular expression should match: " + v.String())
+	}
+
+	if v := Get("http://mytest.com/regexp/x?lang=es"); v.StatusCode != StatusMockUnmatched {
+		t.Fatal("Regular expression should not match")
+	}
+}
+
+func TestMockupExactBeforePattern(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	AddMockups(
+		&Mock{
+			URL:          "http://mytest.com/precedence/1",
+			HTTPMethod:   http.MethodGet,
+			RespHTTPCode: http.StatusOK,
+			RespBody:     "exact",
+		},
+		&Mock{
+			URL:          "http://mytest.com/precedence/{id}",
+			HTTPMethod:   http.MethodGet,
+			ReqHeaders:   http.Header{"X-Test": {"test"}},
+			RespHTTPCode: http.StatusOK,
+			RespBody:     "pattern",
+		},
+	)
+
+	if v := Get("http://mytest.com/precedence/1", WithHeader("X-Test", "test")); v.String() != "exact" {
+		t.Fatal("Exact URL should take precedence: " + v.String())
+	}
+
+	if v := Get("http://mytest.com/precedence/2", WithHeader("X-Test", "test")); v.String() != "pattern" {
+		t.Fatal("Pattern should match: " + v.String())
+	}
+}
+
+func TestMockupInvalidPattern(t *testing.T) {
+
+	ms := NewMockServer(t)
+
+	err := ms.AddMockups(
+		&Mock{URL: "http://mytest.com/valid", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK},
+		&Mock{URL: "^http://mytest.com/(unclosed", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK},
+	)
+
+	if err == nil {
+		t.Fatal("Invalid regular expressions should fail")
+	}
+
+	rb := &RequestBuilder{MockServer: ms}
+
+	if v := rb.Get("http://mytest.com/valid"); v.StatusCode != StatusMockUnmatched {
+		t.Fatal("No Mock should be added if any is invalid", v.StatusCode)
+	}
+}
+
+func TestMockupQueryNotPattern(t *testing.T) {
+
+	ms := NewMockServer(t)
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/search?q=*&fields={id,name}",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "exact",
+	})
+
+	rb := &RequestBuilder{MockServer: ms}
+
+	if v := rb.Get("http://mytest.com/search?q=*&fields={id,name}"); v.String() != "exact" {
+		t.Fatal("Queries should not make a pattern: " + v.String())
+	}
+
+	if v := rb.Get("http://mytest.com/search?q=a&fields=id"); v.StatusCode != StatusMockUnmatched {
+		t.Fatal("Queries should match exactly", v.StatusCode)
+	}
+}
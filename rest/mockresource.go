@@ -0,0 +1,448 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MockResource simulates a REST collection in the mockup server, backed by
+// an in-memory store, so CRUD flows could be tested:
+//
+//	POST   http://api.com/users       creates a user, with a generated id
+//	GET    http://api.com/users       lists the users
+//	GET    http://api.com/users/{id}  gets a user
+//	PUT    http://api.com/users/{id}  replaces a user
+//	PATCH  http://api.com/users/{id}  merges a JSON Merge Patch into a user
+//	DELETE http://api.com/users/{id}  deletes a user
+//
+// Items are JSON objects. Responses have an ETag, computed from the item.
+// Numbers are json.Number, as they are not converted when stored.
+//
+// A MockResource must not be copied after first use.
+type MockResource struct {
+
+	// URL of the collection, like http://api.com/users
+	URL string
+
+	// Field of the items with their id. Default is "id"
+	IDField string
+
+	// Honor If-Match in PUT, PATCH and DELETE, answering 412 Precondition
+	// Failed if the item changed, and If-None-Match in GET, answering
+	// 304 Not Modified if it didn't.
+	Conditional bool
+
+	mtx    sync.Mutex
+	items  map[string]map[string]interface{}
+	ids    []string
+	nextID int64
+}
+
+// AddResource adds the Mocks simulating the resource to the global mockup
+// server. See MockResource
+func AddResource(r *MockResource) error {
+	return defaultMockServer.AddResource(r)
+}
+
+// AddResource adds the Mocks simulating the resource. Explicit Mocks of the
+// same URLs could be added as well, as they take precedence.
+//
+// It returns the error of AddMockups, if any.
+func (s *MockServer) AddResource(r *MockResource) error {
+
+	collection := "^" + regexp.QuoteMeta(strings.TrimRight(r.URL, "/"))
+	query := `(\?.*)?$`
+
+	var mocks []*Mock
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		mocks = append(mocks, &Mock{
+			URL:        collection + query,
+			HTTPMethod: method,
+			Responder:  r.respond,
+		})
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		mocks = append(mocks, &Mock{
+			URL:        collection + `/(?P<id>[^/?]+)` + query,
+			HTTPMethod: method,
+			Responder:  r.respond,
+		})
+	}
+
+	return s.AddMockups(mocks...)
+}
+
+// Seed adds items to the resource. Items are anything marshalled to a JSON
+// object. Those without id get a generated one.
+func (r *MockResource) Seed(items ...interface{}) error {
+
+	for _, item := range items {
+
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+
+		obj, err := decodeItem(data)
+		if err != nil {
+			return err
+		}
+
+		r.mtx.Lock()
+		r.put(obj)
+		r.mtx.Unlock()
+	}
+
+	return nil
+}
+
+// Load adds the items of a JSON or YAML fixture file, an object or a list of
+// them, to the resource.
+func (r *MockResource) Load(path string) error {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	fixture, err := splitFixture(path, data, isYAMLFile(path))
+	if err != nil {
+		return err
+	}
+
+	items := make([]map[string]interface{}, len(fixture))
+
+	for i, it := range fixture {
+		if items[i], err = decodeItem(it.data); err != nil {
+			return &FixtureError{path, it.line, err}
+		}
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for _, item := range items {
+		r.put(item)
+	}
+
+	return nil
+}
+
+// Items returns a copy of the items of the resource, in the order they were
+// added.
+func (r *MockResource) Items() []map[string]interface{} {
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	items := make([]map[string]interface{}, 0, len(r.ids))
+	for _, id := range r.ids {
+		items = append(items, copyItem(r.items[id]))
+	}
+
+	return items
+}
+
+// Item returns a copy of the item with the id, if any.
+func (r *MockResource) Item(id string) (map[string]interface{}, bool) {
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	item, ok := r.items[id]
+	if !ok {
+		return nil, false
+	}
+
+	return copyItem(item), true
+}
+
+// Len returns the amount of items of the resource.
+func (r *MockResource) Len() int {
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return len(r.ids)
+}
+
+func (r *MockResource) idField() string {
+
+	if r.IDField == "" {
+		return "id"
+	}
+
+	return r.IDField
+}
+
+// put stores the item, replacing the one with the same id, if any. Items
+// without id get a generated one. r.mtx must be held.
+func (r *MockResource) put(item map[string]interface{}) string {
+
+	if r.items == nil {
+		r.items = make(map[string]map[string]interface{})
+	}
+
+	id, ok := itemID(item[r.idField()])
+	if !ok {
+		id = r.newID()
+		item[r.idField()] = json.Number(id)
+	}
+
+	// Generated ids never collide with the seeded ones
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil && n > r.nextID {
+		r.nextID = n
+	}
+
+	if _, exists := r.items[id]; !exists {
+		r.ids = append(r.ids, id)
+	}
+
+	r.items[id] = item
+
+	return id
+}
+
+// newID returns the next free numeric id. r.mtx must be held.
+func (r *MockResource) newID() string {
+
+	for {
+		r.nextID++
+
+		id := strconv.FormatInt(r.nextID, 10)
+		if _, exists := r.items[id]; !exists {
+			return id
+		}
+	}
+}
+
+func (r *MockResource) delete(id string) {
+
+	delete(r.items, id)
+
+	for i, old := range r.ids {
+		if old == id {
+			r.ids = append(r.ids[:i], r.ids[i+1:]...)
+			break
+		}
+	}
+}
+
+// respond is the Responder of every Mock of the resource.
+func (r *MockResource) respond(req *http.Request) MockResponse {
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return resourceError(http.StatusBadRequest, err.Error())
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	id, isItem := MockVars(req)["id"]
+
+	if !isItem {
+		if req.Method == http.MethodPost {
+			return r.create(body)
+		}
+		return r.list()
+	}
+
+	item, exists := r.items[id]
+	if !exists {
+		return resourceError(http.StatusNotFound, "not found")
+	}
+
+	etag := itemETag(item)
+
+	if r.Conditional {
+		if req.Method == http.MethodGet {
+			if inm := req.Header.Get("If-None-Match"); inm != "" && etagMatch(inm, etag) {
+				return MockResponse{HTTPCode: http.StatusNotModified, Headers: http.Header{"Etag": {etag}}}
+			}
+		} else if im := req.Header.Get("If-Match"); im != "" && !etagMatch(im, etag) {
+			return resourceError(http.StatusPreconditionFailed, "precondition failed")
+		}
+	}
+
+	switch req.Method {
+
+	case http.MethodPut:
+		replacement, err := decodeItem(body)
+		if err != nil {
+			return resourceError(http.StatusBadRequest, err.Error())
+		}
+
+		replacement[r.idField()] = item[r.idField()]
+		item = replacement
+
+	case http.MethodPatch:
+		patch, err := decodeItem(body)
+		if err != nil {
+			return resourceError(http.StatusBadRequest, err.Error())
+		}
+
+		item = mergePatch(copyItem(item), patch)
+		item[r.idField()] = r.items[id][r.idField()]
+
+	case http.MethodDelete:
+		r.delete(id)
+		return MockResponse{HTTPCode: http.StatusNoContent}
+	}
+
+	r.items[id] = item
+
+	return itemResponse(http.StatusOK, item)
+}
+
+// create stores a new item, with a generated id. r.mtx must be held.
+func (r *MockResource) create(body []byte) MockResponse {
+
+	item, err := decodeItem(body)
+	if err != nil {
+		return resourceError(http.StatusBadRequest, err.Error())
+	}
+
+	delete(item, r.idField())
+	id := r.put(item)
+
+	resp := itemResponse(http.StatusCreated, item)
+	resp.Headers.Set("Location", strings.TrimRight(r.URL, "/")+"/"+id)
+
+	return resp
+}
+
+// list returns every item. r.mtx must be held.
+func (r *MockResource) list() MockResponse {
+
+	items := make([]interface{}, 0, len(r.ids))
+	for _, id := range r.ids {
+		items = append(items, r.items[id])
+	}
+
+	data, _ := json.Marshal(items)
+
+	return MockResponse{
+		HTTPCode: http.StatusOK,
+		Headers:  http.Header{"Content-Type": {"application/json"}},
+		Body:     string(data),
+	}
+}
+
+func itemResponse(code int, item map[string]interface{}) MockResponse {
+
+	data, _ := json.Marshal(item)
+
+	return MockResponse{
+		HTTPCode: code,
+		Headers:  http.Header{"Content-Type": {"application/json"}, "Etag": {itemETag(item)}},
+		Body:     string(data),
+	}
+}
+
+func resourceError(code int, message string) MockResponse {
+
+	data, _ := json.Marshal(map[string]interface{}{"status": code, "message": message})
+
+	return MockResponse{
+		HTTPCode: code,
+		Headers:  http.Header{"Content-Type": {"application/json"}},
+		Body:     string(data),
+	}
+}
+
+// decodeItem decodes a JSON object, keeping numbers as json.Number.
+func decodeItem(data []byte) (map[string]interface{}, error) {
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var item map[string]interface{}
+	if err := dec.Decode(&item); err != nil || item == nil {
+		return nil, errors.New("expected an object")
+	}
+
+	return item, nil
+}
+
+// itemID returns the id of an item as string, if it is a string or a number.
+func itemID(v interface{}) (string, bool) {
+
+	switch id := v.(type) {
+	case string:
+		return id, id != ""
+	case json.Number:
+		return string(id), true
+	}
+
+	return "", false
+}
+
+// itemETag is a strong ETag computed from the item.
+func itemETag(item map[string]interface{}) string {
+
+	data, _ := json.Marshal(item)
+
+	h := fnv.New64a()
+	h.Write(data)
+
+	return fmt.Sprintf(`"%x"`, h.Sum64())
+}
+
+// etagMatch tells if the value of If-Match or If-None-Match matches the ETag.
+func etagMatch(header string, etag string) bool {
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergePatch applies a JSON Merge Patch (RFC 7386): null removes a member,
+// objects are merged, and anything else replaces the member.
+func mergePatch(target map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+
+	for k, v := range patch {
+
+		switch value := v.(type) {
+		case nil:
+			delete(target, k)
+
+		case map[string]interface{}:
+			obj, ok := target[k].(map[string]interface{})
+			if !ok {
+				obj = make(map[string]interface{})
+			}
+			target[k] = mergePatch(obj, value)
+
+		default:
+			target[k] = v
+		}
+	}
+
+	return target
+}
+
+// copyItem returns a deep copy of the item.
+func copyItem(item map[string]interface{}) map[string]interface{} {
+
+	data, _ := json.Marshal(item)
+	c, _ := decodeItem(data)
+
+	return c
+}
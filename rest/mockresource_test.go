@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestMockResource(t *testing.T) {
+
+	ms := NewMockServer(t)
+	users := &MockResource{URL: "http://mytest.com/users", Conditional: true}
+	if err := ms.AddResource(users); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.Seed(User{ID: 7, Name: "Seeded"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without cache, so 304 responses are not replaced by the cached ones
+	rb := &RequestBuilder{MockServer: ms, DisableCache: true}
+
+	resp := rb.Post("http://mytest.com/users", map[string]interface{}{"name": "Alice", "age": 30})
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") != "http://mytest.com/users/8" {
+		t.Fatal("Wrong create response", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	resp = rb.Get("http://mytest.com/users/8")
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" || resp.String() != `{"age":30,"id":8,"name":"Alice"}` {
+		t.Fatal("Wrong get response", resp.StatusCode, resp.String())
+	}
+
+	if resp = rb.Get("http://mytest.com/users/8", WithHeader("If-None-Match", etag)); resp.Err != nil || resp.StatusCode != http.StatusNotModified {
+		t.Fatal("Unchanged items should not be modified", resp.Err)
+	}
+
+	resp = rb.Patch("http://mytest.com/users/8", map[string]interface{}{"age": nil, "city": "Rosario"}, WithHeader("If-Match", etag))
+	if resp.StatusCode != http.StatusOK || resp.String() != `{"city":"Rosario","id":8,"name":"Alice"}` {
+		t.Fatal("Wrong patch response", resp.StatusCode, resp.String())
+	}
+
+	if resp = rb.Put("http://mytest.com/users/8", map[string]interface{}{"name": "Bob"}, WithHeader("If-Match", etag)); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatal("Stale writes should fail", resp.StatusCode)
+	}
+
+	if resp = rb.Put("http://mytest.com/users/8", map[string]interface{}{"name": "Bob"}); resp.String() != `{"id":8,"name":"Bob"}` {
+		t.Fatal("Wrong put response", resp.StatusCode, resp.String())
+	}
+
+	var list []User
+	if resp = rb.Get("http://mytest.com/users?page=1"); resp.FillUp(&list) != nil || len(list) != 2 || list[1].Name != "Bob" {
+		t.Fatal("Wrong list response", resp.String())
+	}
+
+	if resp = rb.Delete("http://mytest.com/users/7"); resp.StatusCode != http.StatusNoContent {
+		t.Fatal("Wrong delete response", resp.StatusCode)
+	}
+
+	if resp = rb.Get("http://mytest.com/users/7"); resp.StatusCode != http.StatusNotFound {
+		t.Fatal("Deleted items should not be found", resp.StatusCode)
+	}
+
+	if item, ok := users.Item("8"); !ok || item["name"] != "Bob" || users.Len() != 1 {
+		t.Fatal("Wrong state", item, users.Len())
+	}
+}
+
+func TestMockResourceLoad(t *testing.T) {
+
+	dir := writeFixtures(t, map[string]string{
+		"users.json": `[
+			{"id": "alice", "name": "Alice"},
+			{"name": "Bob"}
+		]`,
+		"bad.json": `[
+			{"id": 1},
+			"hello"
+		]`,
+		"more.yaml": `
+- id: carol
+  name: Carol
+- name: Dave
+`,
+		"bad.yaml": `
+- id: 1
+- hello
+`,
+	})
+
+	users := &MockResource{URL: "http://mytest.com/users"}
+
+	if err := users.Load(filepath.Join(dir, "users.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	items := users.Items()
+	if len(items) != 2 || items[0]["id"] != "alice" || items[1]["id"] != json.Number("1") {
+		t.Fatal("Wrong items", items)
+	}
+
+	if err := users.Load(filepath.Join(dir, "more.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	items = users.Items()
+	if len(items) != 4 || items[2]["id"] != "carol" || items[3]["id"] != json.Number("2") {
+		t.Fatal("Wrong items", items)
+	}
+
+	for _, name := range []string{"bad.json", "bad.yaml"} {
+		err := users.Load(filepath.Join(dir, name))
+		if fe, ok := err.(*FixtureError); !ok || fe.Line != 3 {
+			t.Fatal("Wrong error", name, err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMockupSequence(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	responses := []MockResponse{
+		{HTTPCode: http.StatusInternalServerError},
+		{HTTPCode: http.StatusInternalServerError},
+		{HTTPCode: http.StatusOK, Body: "ok"},
+	}
+
+	AddMockups(
+		&Mock{
+			URL:        "http://mytest.com/sequence/repeat",
+			HTTPMethod: http.MethodGet,
+			Responses:  responses,
+		},
+		&Mock{
+			URL:         "http://mytest.com/sequence/cycle",
+			HTTPMethod:  http.MethodGet,
+			Responses:   responses,
+			OnExhausted: SequenceCycle,
+		},
+		&Mock{
+			URL:         "http://mytest.com/sequence/fail",
+			HTTPMethod:  http.MethodGet,
+			Responses:   responses,
+			OnExhausted: SequenceFail,
+		},
+	)
+
+	expected := map[string][]int{
+		"repeat": {500, 500, 200, 200, 200},
+		"cycle":  {500, 500, 200, 500, 500},
+		"fail":   {500, 500, 200, StatusMockUnmatched, StatusMockUnmatched},
+	}
+
+	for name, codes := range expected {
+		for i, code := range codes {
+			if v := Get("http://mytest.com/sequence/" + name); v.StatusCode != code {
+				t.Fatalf("%s: call %d got %d, expected %d", name, i, v.StatusCode, code)
+			}
+		}
+	}
+}
+
+func TestMockupScenario(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	myURL := "http://mytest.com/scenario/user"
+
+	AddMockups(
+		&Mock{
+			URL:           myURL,
+			HTTPMethod:    http.MethodGet,
+			Scenario:      "user",
+			RequiredState: ScenarioStarted,
+			RespHTTPCode:  http.StatusNotFound,
+		},
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodPost,
+			Scenario:     "user",
+			NewState:     "created",
+			RespHTTPCode: http.StatusCreated,
+		},
+		&Mock{
+			URL:           myURL,
+			HTTPMethod:    http.MethodGet,
+			Scenario:      "user",
+			RequiredState: "created",
+			RespHTTPCode:  http.StatusOK,
+			RespBody:      "user",
+		},
+	)
+
+	if v := Get(myURL); v.StatusCode != http.StatusNotFound {
+		t.Fatal("Status != Not Found (404)")
+	}
+
+	if v := Post(myURL, &User{Name: "Hernan"}); v.StatusCode != http.StatusCreated {
+		t.Fatal("Status != Created (201)")
+	}
+
+	if state := MockScenarioState("user"); state != "created" {
+		t.Fatal("Wrong scenario state: " + state)
+	}
+
+	if v := Get(myURL); v.StatusCode != http.StatusOK || v.String() != "user" {
+		t.Fatal("Status != OK (200)")
+	}
+
+	SetMockScenarioState("user", ScenarioStarted)
+
+	if v := Get(myURL); v.StatusCode != http.StatusNotFound {
+		t.Fatal("Status != Not Found (404)")
+	}
+}
+
+func TestMockupResponder(t *testing.T) {
+
+	ms := NewMockServer(t)
+	rb := &RequestBuilder{MockServer: ms}
+
+	ms.AddMockups(
+		&Mock{
+			URL:        "http://mytest.com/responder/users",
+			HTTPMethod: http.MethodPost,
+			Responder: func(req *http.Request) MockResponse {
+				var u User
+				if err := json.NewDecoder(req.Body).Decode(&u); err != nil {
+					return MockResponse{HTTPCode: http.StatusBadRequest}
+				}
+
+				u.ID = 42
+				body, _ := json.Marshal(u)
+
+				return MockResponse{HTTPCode: http.StatusCreated, Body: string(body)}
+			},
+		},
+		&Mock{
+			URL:        "http://mytest.com/responder/users/{id}",
+			HTTPMethod: http.MethodGet,
+			Latency:    FixedLatency(10 * time.Millisecond),
+			Responder: func(req *http.Request) MockResponse {
+				if req.URL.Host != "mytest.com" || req.Header.Get("X-Original-URL") != "" {
+					return MockResponse{HTTPCode: http.StatusBadRequest}
+				}
+
+				id, _ := strconv.Atoi(MockVars(req)["id"])
+				if id%2 != 0 {
+					return MockResponse{HTTPCode: http.StatusNotFound}
+				}
+
+				return MockResponse{Body: `{"id": {id}}`}
+			},
+		},
+	)
+
+	resp := rb.Post("http://mytest.com/responder/users", &User{Name: "Hernan"})
+	if resp.StatusCode != http.StatusCreated || resp.String() != `{"id":42,"name":"Hernan"}` {
+		t.Fatal("Wrong response", resp.StatusCode, resp.String())
+	}
+
+	start := time.Now()
+
+	if resp := rb.Get("http://mytest.com/responder/users/1"); resp.StatusCode != http.StatusNotFound {
+		t.Fatal("Wrong status", resp.StatusCode)
+	}
+
+	if time.Since(start) < 10*time.Millisecond {
+		t.Fatal("The Mock Latency should be used")
+	}
+
+	// Computed bodies are not expanded
+	if resp := rb.Get("http://mytest.com/responder/users/2"); resp.StatusCode != http.StatusOK || resp.String() != `{"id": {id}}` {
+		t.Fatal("Wrong response", resp.StatusCode, resp.String())
+	}
+
+	if len(ms.MockCalls()) != 3 || len(ms.UnmatchedCalls()) != 0 {
+		t.Fatal("Calls should be recorded")
+	}
+}
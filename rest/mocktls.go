@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+)
+
+// MockServerOption configures a MockServer.
+type MockServerOption interface {
+	apply(*MockServer)
+}
+
+type mockOptionFunc func(*MockServer)
+
+func (f mockOptionFunc) apply(s *MockServer) {
+	f(s)
+}
+
+// MockTLS serves HTTPS, with a self-signed certificate valid for 127.0.0.1.
+// RequestBuilders sending requests to the MockServer trust it.
+func MockTLS() MockServerOption {
+	return mockOptionFunc(func(s *MockServer) {
+		s.tls = true
+	})
+}
+
+// MockTLSCertificate serves HTTPS with the given certificate, which must be
+// valid for 127.0.0.1. RequestBuilders sending requests to the MockServer
+// trust it.
+func MockTLSCertificate(cert tls.Certificate) MockServerOption {
+	return mockOptionFunc(func(s *MockServer) {
+		s.tls = true
+		s.tlsConfig().Certificates = []tls.Certificate{cert}
+	})
+}
+
+// MockClientCAs serves HTTPS, requiring client certificates signed by one of
+// the CAs, so mutual TLS could be tested. RequestBuilders present their
+// certificates with the TLSClientConfig of their CustomPool.
+func MockClientCAs(cas *x509.CertPool) MockServerOption {
+	return mockOptionFunc(func(s *MockServer) {
+		s.tls = true
+		s.tlsConfig().ClientCAs = cas
+		s.tlsConfig().ClientAuth = tls.RequireAndVerifyClientCert
+	})
+}
+
+func (s *MockServer) tlsConfig() *tls.Config {
+
+	if s.serverTLS == nil {
+		s.serverTLS = new(tls.Config)
+	}
+
+	return s.serverTLS
+}
+
+// transport returns a clone of the transport, which trusts the certificate
+// of the server, if it serves HTTPS. Otherwise it returns the transport.
+func (s *MockServer) transport(base *http.Transport) *http.Transport {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.server == nil || s.server.TLS == nil {
+		return base
+	}
+
+	if tr, ok := s.transports[base]; ok {
+		return tr
+	}
+
+	tr := base.Clone()
+
+	if tr.TLSClientConfig == nil {
+		tr.TLSClientConfig = new(tls.Config)
+	}
+
+	roots := x509.NewCertPool()
+	if tr.TLSClientConfig.RootCAs != nil {
+		roots = tr.TLSClientConfig.RootCAs.Clone()
+	}
+
+	roots.AddCert(s.server.Certificate())
+	tr.TLSClientConfig.RootCAs = roots
+
+	if s.transports == nil {
+		s.transports = make(map[*http.Transport]*http.Transport)
+	}
+
+	s.transports[base] = tr
+
+	return tr
+}
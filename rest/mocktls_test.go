@@ -0,0 +1,107 @@
+package rest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMockupTLS(t *testing.T) {
+
+	ms := NewMockServer(t, MockTLS())
+	ms.AddMockups(&Mock{
+		URL:          "https://mytest.com/tls",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "secure",
+	})
+
+	rb := &RequestBuilder{MockServer: ms}
+
+	resp := rb.Get("https://mytest.com/tls")
+	if resp.Err != nil || resp.String() != "secure" || resp.TLS == nil {
+		t.Fatal("Wrong response", resp.Err)
+	}
+}
+
+func TestMockupMutualTLS(t *testing.T) {
+
+	ca, caKey := newTestCertificate(t, nil, nil)
+	client, clientKey := newTestCertificate(t, ca, caKey)
+
+	cas := x509.NewCertPool()
+	cas.AddCert(ca)
+
+	ms := NewMockServer(t, MockClientCAs(cas))
+	ms.AddMockups(&Mock{
+		URL:          "https://mytest.com/mtls",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+	})
+
+	anonymous := &RequestBuilder{MockServer: ms, CustomPool: &CustomPool{MaxIdleConnsPerHost: 1}}
+
+	if resp := anonymous.Get("https://mytest.com/mtls"); resp.Err == nil {
+		t.Fatal("A client certificate should be required")
+	}
+
+	authenticated := &RequestBuilder{
+		MockServer: ms,
+		CustomPool: &CustomPool{
+			MaxIdleConnsPerHost: 1,
+			TLSClientConfig: &tls.Config{
+				Certificates: []tls.Certificate{{Certificate: [][]byte{client.Raw}, PrivateKey: clientKey}},
+			},
+		},
+	}
+
+	if resp := authenticated.Get("https://mytest.com/mtls"); resp.Err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatal("The client certificate should be accepted", resp.Err)
+	}
+}
+
+// newTestCertificate creates a CA certificate if parent is nil. Otherwise,
+// a client certificate signed by parent.
+func newTestCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	if parent == nil {
+		template.Subject.CommonName = "ca"
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
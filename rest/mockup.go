@@ -1,42 +1,98 @@
 package rest
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
 )
 
-var mockUpEnv bool
-var mockMap = make(map[string]*Mock)
+// Whether requests are sent to the global mockup server.
+var mockUpEnv atomic.Bool
 
-var mockServer *httptest.Server
-var mux *http.ServeMux
-
-var mockServerURL *url.URL
+// The global mockup server. Requests are sent to it once the mockup
+// environment is activated, unless their RequestBuilder has its own
+// MockServer.
+var defaultMockServer = newMockServer()
 
 // Mock serves the purpose of creating Mockups.
 // All requests will be sent to the mockup server if mockup is activated.
-// To activate the mockup *environment* you have two ways: using the flag -mock
+// To activate the mockup *environment* you have three ways: using the flag
+// -mock, in tests
 //	go test -mock
 //
+// Setting the RESTFUL_MOCK environment variable
+//	RESTFUL_MOCK=1 go test
+//
 // Or by programmatically starting the mockup server
 // 	StartMockupServer()
+//
+// A RequestBuilder could send its requests to its own MockServer as well.
+//
+// A request matches a Mock if HTTP Method and URL are the same, query
+// parameters are the same in any order, the request has all the ReqHeaders,
+// and its body matches ReqBody and ReqBodyFunc, if set.
+// When many Mocks match a request, the most specific one is used: the one
+// that matches more headers and body conditions. On a draw, the latest added.
 type Mock struct {
 
-	// Request URL
+	// Request URL.
+	// It could be a pattern as well: a path template, a glob or a regular
+	// expression. Exact URLs take precedence over patterns.
+	//
+	//	http://api.com/users/{id}
+	//	http://api.com/users/*/items/**
+	//	^http://api\.com/users/(?P<id>\d+)$
+	//
+	// In templates {id} captures one path segment, and {+path} many.
+	// In globs * matches anything but a slash, and ** anything.
+	// Regular expressions start with ^, and they are matched against the
+	// whole URL, query included.
+	//
+	// The variables captured by a pattern, like {id}, are replaced in
+	// RespBody and RespHeaders.
+	//
+	// URLs which are just a path, like /users/{id}, match the requests sent
+	// straight to the server, rather than through a RequestBuilder.
+	// See MockBaseURL
 	URL string
 
 	// Request HTTP Method (GET, POST, PUT, PATCH, HEAD, DELETE, OPTIONS)
 	// As a good practice use the constants in http package (http.MethodGet, etc.)
 	HTTPMethod string
 
-	// Request array Headers
+	// Request array Headers.
+	// The request must have all of them, but it may have others.
 	ReqHeaders http.Header
 
-	// Request Body, used with POST, PUT & PATCH
+	// Request Body, used with POST, PUT & PATCH.
+	// If empty, any body matches.
 	ReqBody string
 
+	// How ReqBody is compared with the body of the request.
+	// Default is BodyExact
+	ReqBodyMatch BodyMatch
+
+	// Request Body predicate. If set, the body of the request must satisfy it.
+	ReqBodyFunc func(body []byte) bool
+
 	// Response HTTP Code
 	RespHTTPCode int
 
@@ -45,83 +101,919 @@ type Mock struct {
 
 	// Response Body
 	RespBody string
+
+	// Responses for each call, in order. If set, they are used instead of
+	// RespHTTPCode, RespHeaders & RespBody.
+	Responses []MockResponse
+
+	// Responder computes the response from the request. If set, it is used
+	// instead of the static responses. The request has the URL sent by the
+	// client, and the variables captured by the Mock URL pattern are
+	// available with MockVars. HTTPCode defaults to 200.
+	Responder func(req *http.Request) MockResponse
+
+	// What to do once every one of the Responses has been used.
+	// Default is SequenceRepeatLast
+	OnExhausted SequenceMode
+
+	// Scenario the Mock belongs to. All the Mocks in a Scenario share
+	// a state, which is ScenarioStarted at first.
+	Scenario string
+
+	// The Mock matches only if its Scenario is in this state.
+	// If empty, it matches in any state.
+	RequiredState string
+
+	// Once the Mock is used, its Scenario moves to this state, if set.
+	NewState string
+
+	// How long to wait before answering. Default is no wait.
+	Latency Latency
+
+	// Failure to simulate instead of answering. Default is NoFault
+	Fault Fault
+
+	query   url.Values
+	pattern *mockPattern
+	calls   int
+
+	// Generated from an OpenAPI document
+	generated bool
+
+	// Request headers which must be present, with any value, like the
+	// redacted ones of a Cassette.
+	present []string
 }
 
-// StartMockupServer sets the environment to send all client requests
-// to the mockup server.
-func StartMockupServer() {
+// MockResponse is a response of a Mock with a sequence of Responses.
+type MockResponse struct {
+	HTTPCode int
+	Headers  http.Header
+	Body     string
+
+	// Latency and Fault of this response. If not set, the ones of the Mock
+	// are used.
+	Latency Latency
+	Fault   Fault
+}
+
+// SequenceMode tells what a Mock does once every one of its Responses
+// has been used.
+type SequenceMode int
+
+const (
+	// SequenceRepeatLast keeps answering with the last response.
+	SequenceRepeatLast SequenceMode = iota
+
+	// SequenceCycle starts over from the first response.
+	SequenceCycle
+
+	// SequenceFail stops matching requests. They are matched against other
+	// Mocks instead, if any.
+	SequenceFail
+)
+
+// ScenarioStarted is the state every Scenario starts at.
+const ScenarioStarted = "Started"
+
+// BodyMatch tells how the ReqBody of a Mock is compared with the body of a
+// request.
+type BodyMatch int
+
+const (
+	// BodyExact matches bodies that are equal byte by byte.
+	BodyExact BodyMatch = iota
+
+	// BodyJSON matches bodies that are equal once unmarshalled as JSON,
+	// regardless of formatting and the order of the keys.
+	BodyJSON
+)
+
+// MockServer is a mockup server, with its own Mocks, scenarios and recorded
+// calls. Tests could create their own, and bind it to a RequestBuilder, so
+// they are isolated from each other, even when they run in parallel.
+//
+//	func TestUser(t *testing.T) {
+//		t.Parallel()
+//
+//		ms := rest.NewMockServer(t)
+//		ms.AddMockups(&rest.Mock{...})
+//
+//		rb := &rest.RequestBuilder{MockServer: ms}
+//		resp := rb.Get("http://api.com/users/1")
+//	}
+//
+// The package level functions, like AddMockups, use the global mockup
+// server. A Mock should be added to only one MockServer.
+type MockServer struct {
+
+	// Guards Mocks, their state, the state of the scenarios, and the calls
+	mtx sync.Mutex
+
+	// Mocks by HTTP Method and URL, without query.
+	// The latest added Mock is last.
+	mocks map[string][]*Mock
+
+	// Mocks whose URL is a pattern, in the order they were added.
+	patterns []*Mock
+
+	// Current state of each scenario
+	scenarios map[string]string
+
+	// Every request received, in order. Only the latest maxCalls, if set.
+	calls    []MockCall
+	maxCalls int
+
+	// Test failed by unmatched requests, if any.
+	unmatchedTB testing.TB
+
+	// Strict test, checked before the Mocks are flushed, if any.
+	strictTB testing.TB
+
+	// OpenAPI contract the requests are validated against, if any.
+	contract *openAPIContract
+
+	server *httptest.Server
+	url    *url.URL
+
+	// HTTPS, and its configuration
+	tls       bool
+	serverTLS *tls.Config
+
+	// Listener to serve on, instead of a random local port. Used once.
+	listener net.Listener
+
+	// Base URL of the requests sent straight to the server
+	directBase string
+
+	// Logs every request, if set
+	logger *log.Logger
+
+	// Clones of the client transports, trusting the server certificate
+	transports map[*http.Transport]*http.Transport
+
+	// Closed when the server is closed, so stalled requests end.
+	stop chan struct{}
+}
+
+func newMockServer() *MockServer {
+	return &MockServer{
+		mocks:     make(map[string][]*Mock),
+		scenarios: make(map[string]string),
+	}
+}
+
+// NewMockServer starts a mockup server, without Mocks. It is closed once the
+// test finishes. If t is nil, it must be closed with Close.
+func NewMockServer(t testing.TB, opts ...MockServerOption) *MockServer {
+
+	s := newMockServer()
+
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+
+	s.start()
+
+	if t != nil {
+		t.Cleanup(s.Close)
+	}
+
+	return s
+}
+
+// URL returns the base URL of the server, like http://127.0.0.1:4321.
+// It is empty if the server is closed.
+func (s *MockServer) URL() string {
+
+	if u := s.baseURL(); u != nil {
+		return u.String()
+	}
+
+	return ""
+}
+
+func (s *MockServer) baseURL() *url.URL {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.url
+}
+
+func (s *MockServer) start() {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.server != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.transports = nil
+
+	s.server = httptest.NewUnstartedServer(s)
+
+	if s.listener != nil {
+		s.server.Listener.Close()
+		s.server.Listener, s.listener = s.listener, nil
+	}
+
+	if s.tls {
+		s.server.TLS = s.serverTLS.Clone()
+		s.server.StartTLS()
+	} else {
+		s.server.Start()
+	}
+
+	var err error
+	if s.url, err = url.Parse(s.server.URL); err != nil {
+		panic(err)
+	}
+}
+
+// Close shuts the server down. Its Mocks are kept.
+func (s *MockServer) Close() {
 
-	mockUpEnv = true
+	s.mtx.Lock()
+	server, stop, transports := s.server, s.stop, s.transports
+	s.server, s.url, s.stop, s.transports = nil, nil, nil, nil
+	s.mtx.Unlock()
+
+	if server == nil {
+		return
+	}
 
-	if mockServer == nil {
-		startMockupServ()
+	close(stop)
+	server.Close()
+
+	for _, tr := range transports {
+		tr.CloseIdleConnections()
+	}
+}
+
+// StartMockupServer sets the environment to send all client requests
+// to the mockup server.
+// Options, if any, restart the mockup server with them, like MockTLS.
+func StartMockupServer(opts ...MockServerOption) {
+
+	if len(opts) > 0 {
+		defaultMockServer.Close()
+
+		defaultMockServer.mtx.Lock()
+		defaultMockServer.tls, defaultMockServer.serverTLS = false, nil
+		defaultMockServer.listener, defaultMockServer.directBase, defaultMockServer.logger = nil, "", nil
+		defaultMockServer.maxCalls = 0
+		for _, opt := range opts {
+			opt.apply(defaultMockServer)
+		}
+		defaultMockServer.mtx.Unlock()
 	}
+
+	mockUpEnv.Store(true)
+	defaultMockServer.start()
 }
 
 // StopMockupServer stop sending requests to the mockup server
 func StopMockupServer() {
 
-	mockUpEnv = false
-	mockServer.Close()
+	mockUpEnv.Store(false)
+	defaultMockServer.Close()
+}
+
+// The -mock flag, registered only in test binaries, so go test -mock
+// activates the mockup environment.
+var mockFlag *bool
+
+// Checks, once, whether the mockup environment is activated by the -mock flag
+// or the RESTFUL_MOCK environment variable.
+var mockEnvOnce sync.Once
 
-	mockServer = nil
-	mockServerURL = nil
-	mux = nil
+func init() {
+	if testing.Testing() && flag.Lookup("mock") == nil {
+		mockFlag = flag.Bool("mock", false,
+			"Use 'mock' flag to tell package rest that you would like to use mockups.")
+	}
 }
 
-func startMockupServ() {
+// checkMockupEnv starts the mockup server if the -mock flag or the
+// RESTFUL_MOCK environment variable (1, true) are set. Until the flags are
+// parsed, the check is postponed.
+func checkMockupEnv() {
+
+	if mockFlag != nil && !flag.Parsed() {
+		return
+	}
 
-	if mockUpEnv {
-		mux = http.NewServeMux()
-		mockServer = httptest.NewServer(mux)
-		mux.HandleFunc("/", mockupHandler)
+	mockEnvOnce.Do(func() {
+		env, _ := strconv.ParseBool(os.Getenv("RESTFUL_MOCK"))
 
-		var err error
-		if mockServerURL, err = url.Parse(mockServer.URL); err != nil {
-			panic(err)
+		if env || (mockFlag != nil && *mockFlag) {
+			StartMockupServer()
 		}
+	})
+}
+
+// mockServer returns the mockup server for the requests of the
+// RequestBuilder, if any.
+func (rb *RequestBuilder) mockServer() *MockServer {
+
+	checkMockupEnv()
 
+	switch {
+	case rb.MockServer != nil:
+		return rb.MockServer
+	case mockUpEnv.Load():
+		return defaultMockServer
 	}
+
+	return nil
 }
 
-func init() {
-	flag.BoolVar(&mockUpEnv, "mock", false,
-		"Use 'mock' flag to tell package rest that you would like to use mockups.")
+// AddMockups adds Mocks to the global mockup server.
+// See MockServer.AddMockups
+func AddMockups(mocks ...*Mock) error {
+	return defaultMockServer.AddMockups(mocks...)
+}
+
+// AddMockups adds Mocks to the mockup server.
+// Adding a Mock for the same HTTP Method, URL and request conditions as a
+// previous one, overrides it.
+//
+// If the URL pattern of any Mock is invalid, none of them is added, and the
+// error is returned.
+func (s *MockServer) AddMockups(mocks ...*Mock) error {
+
+	patterns, err := compileMocks(mocks)
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
 
-	flag.Parse()
-	startMockupServ()
+	s.addMocks(mocks, patterns)
+
+	return nil
 }
 
-// AddMockups ...
-func AddMockups(mocks ...*Mock) {
-	for _, m := range mocks {
-		mockMap[m.HTTPMethod+" "+m.URL] = m
+// ReplaceMockups replaces all the Mocks of the global mockup server.
+// See MockServer.ReplaceMockups
+func ReplaceMockups(mocks ...*Mock) error {
+	return defaultMockServer.ReplaceMockups(mocks...)
+}
+
+// ReplaceMockups replaces all the Mocks at once, so every request matches
+// either the previous Mocks or the new ones. Unlike FlushMockups, the state
+// of the scenarios and the recorded calls are kept.
+//
+// If the URL pattern of any Mock is invalid, the previous Mocks are kept, and
+// the error is returned.
+func (s *MockServer) ReplaceMockups(mocks ...*Mock) error {
+
+	patterns, err := compileMocks(mocks)
+	if err != nil {
+		return err
 	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.mocks = make(map[string][]*Mock)
+	s.patterns = nil
+	s.addMocks(mocks, patterns)
+
+	return nil
 }
 
-// FlushMockups ...
-func FlushMockups() {
-	mockMap = make(map[string]*Mock)
+// compileMocks compiles the URL pattern of each Mock, or nil if its URL is
+// exact.
+func compileMocks(mocks []*Mock) ([]*mockPattern, error) {
+
+	patterns := make([]*mockPattern, len(mocks))
+
+	for i, m := range mocks {
+		if isMockPattern(m.URL) {
+			p, err := compileMockPattern(m.URL)
+			if err != nil {
+				return nil, fmt.Errorf("mock %s %s: %w", m.HTTPMethod, m.URL, err)
+			}
+
+			patterns[i] = p
+		}
+	}
+
+	return patterns, nil
 }
 
-func mockupHandler(writer http.ResponseWriter, req *http.Request) {
+// addMocks adds the Mocks, with their compiled patterns. s.mtx must be held.
+func (s *MockServer) addMocks(mocks []*Mock, patterns []*mockPattern) {
+
+	for i, m := range mocks {
 
-	url := req.Header.Get("X-Original-URL")
+		m.calls = 0
 
-	if m := mockMap[req.Method+" "+url]; m != nil {
+		if patterns[i] != nil {
+			m.pattern = patterns[i]
+			m.query = nil
 
-		// Add headers
-		for k, v := range m.RespHeaders {
-			for _, vv := range v {
-				writer.Header().Add(k, vv)
+			if q := strings.Index(m.URL, "?"); q >= 0 && !m.pattern.withQuery {
+				m.query, _ = url.ParseQuery(m.URL[q+1:])
 			}
+
+			s.patterns = addMock(s.patterns, m)
+			continue
+		}
+
+		key, query := mockKey(m.HTTPMethod, m.URL)
+		m.query = query
+		m.pattern = nil
+
+		s.mocks[key] = addMock(s.mocks[key], m)
+	}
+}
+
+// addMock adds m to mocks, overriding the one with the same request
+// conditions, if any. Generated Mocks never override explicit ones.
+func addMock(mocks []*Mock, m *Mock) []*Mock {
+
+	if m.generated {
+		for _, old := range mocks {
+			if !old.generated && old.sameRequest(m) {
+				return mocks
+			}
+		}
+	}
+
+	return append(removeSameMock(mocks, m), m)
+}
+
+// removeSameMock removes from mocks the one with the same request conditions
+// as m, if any.
+func removeSameMock(mocks []*Mock, m *Mock) []*Mock {
+
+	for i, old := range mocks {
+		if old.sameRequest(m) {
+			return append(mocks[:i:i], mocks[i+1:]...)
+		}
+	}
+
+	return mocks
+}
+
+// sameRequest tells if both Mocks match exactly the same requests.
+func (m *Mock) sameRequest(other *Mock) bool {
+
+	// Functions can't be compared
+	if m.ReqBodyFunc != nil || other.ReqBodyFunc != nil {
+		return false
+	}
+
+	return m.HTTPMethod == other.HTTPMethod &&
+		(m.pattern == nil || m.URL == other.URL) &&
+		sameQuery(m.query, other.query) &&
+		reflect.DeepEqual(m.ReqHeaders, other.ReqHeaders) &&
+		reflect.DeepEqual(m.present, other.present) &&
+		m.ReqBody == other.ReqBody &&
+		m.ReqBodyMatch == other.ReqBodyMatch &&
+		m.Scenario == other.Scenario &&
+		m.RequiredState == other.RequiredState
+}
+
+// FlushMockups removes all the Mocks of the global mockup server, and the
+// recorded calls.
+func FlushMockups() {
+	defaultMockServer.FlushMockups()
+}
+
+// FlushMockups removes all the Mocks, and the recorded calls. If the server
+// is Strict, the test is checked first.
+func (s *MockServer) FlushMockups() {
+
+	s.mtx.Lock()
+	strict := s.strictTB
+	s.mtx.Unlock()
+
+	if strict != nil {
+		s.checkStrict(strict)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.mocks = make(map[string][]*Mock)
+	s.patterns = nil
+	s.scenarios = make(map[string]string)
+	s.calls = nil
+}
+
+// MockScenarioState returns the current state of a Scenario of the global
+// mockup server.
+func MockScenarioState(scenario string) string {
+	return defaultMockServer.ScenarioState(scenario)
+}
+
+// SetMockScenarioState moves a Scenario of the global mockup server to
+// a state.
+func SetMockScenarioState(scenario string, state string) {
+	defaultMockServer.SetScenarioState(scenario, state)
+}
+
+// ScenarioState returns the current state of a Scenario.
+func (s *MockServer) ScenarioState(scenario string) string {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.scenarioState(scenario)
+}
+
+// SetScenarioState moves a Scenario to a state.
+func (s *MockServer) SetScenarioState(scenario string, state string) {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.scenarios[scenario] = state
+}
+
+func (s *MockServer) scenarioState(scenario string) string {
+
+	if state, ok := s.scenarios[scenario]; ok {
+		return state
+	}
+
+	return ScenarioStarted
+}
+
+// ServeHTTP answers a request with the Mock it matches.
+func (s *MockServer) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte(err.Error()))
+		return
+	}
+
+	// Sent straight to the server, not through a RequestBuilder
+	if req.Header.Get("X-Original-URL") == "" {
+		req.Header.Set("X-Original-URL", joinURL(s.directBase, req.URL.RequestURI()))
+	}
+
+	m, resp, vars, stop, diagnostic := s.useMock(req, body)
+
+	if m == nil {
+		writer.Header().Set("X-Restful-Mock", "unmatched")
+		writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		writer.WriteHeader(StatusMockUnmatched)
+		writer.Write([]byte(diagnostic))
+		return
+	}
+
+	// Computed responses are not expanded
+	if m.Responder != nil {
+		resp = m.respond(req, body, vars)
+		vars = nil
+	}
+
+	if !resp.wait(req, stop) {
+		return
+	}
+
+	// Add headers
+	for k, v := range resp.Headers {
+		for _, vv := range v {
+			writer.Header().Add(k, expandMockVars(vv, vars))
 		}
+	}
+
+	respBody := []byte(expandMockVars(resp.Body, vars))
 
-		writer.WriteHeader(m.RespHTTPCode)
-		writer.Write([]byte(m.RespBody))
+	if resp.Fault != NoFault {
+		resp.writeFault(writer, req, respBody, stop)
 		return
 	}
 
-	writer.WriteHeader(http.StatusBadRequest)
-	writer.Write([]byte("MockUp nil!"))
+	writer.WriteHeader(resp.HTTPCode)
+	writer.Write(respBody)
+}
+
+// mockKey returns the key of MockServer mocks for an HTTP Method and URL,
+// and the query parameters of the URL, which are matched apart.
+func mockKey(method string, rawURL string) (string, url.Values) {
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return method + " " + rawURL, nil
+	}
+
+	query := u.Query()
+
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return method + " " + u.String(), query
+}
+
+// useMock finds the Mock for the request, records the call, and uses it.
+// It returns the Mock, its response, the variables captured by its URL
+// pattern, and the channel closed when the server is closed.
+// If no Mock matched, it returns the diagnostic instead.
+func (s *MockServer) useMock(req *http.Request, body []byte) (*Mock, MockResponse, map[string]string, <-chan struct{}, string) {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	violations := s.validate(req, body)
+	m, vars := s.findMock(req, body)
+
+	if m == nil {
+		diagnostic := s.diagnose(req, body)
+		s.recordCall(req, body, nil, diagnostic, violations)
+
+		if s.unmatchedTB != nil {
+			s.unmatchedTB.Errorf("%s", diagnostic)
+		}
+
+		if s.logger != nil {
+			s.logger.Print(diagnostic)
+		}
+
+		return nil, MockResponse{}, nil, s.stop, diagnostic
+	}
+
+	s.recordCall(req, body, m, "", violations)
+
+	if s.logger != nil {
+		s.logger.Printf("%s %s matched %s %s", req.Method, req.Header.Get("X-Original-URL"), m.HTTPMethod, m.URL)
+	}
+
+	return m, m.use(s.scenarios), vars, s.stop, ""
+}
+
+// findMock returns the Mock for the request, if any, and the variables
+// captured by its URL pattern. Exact URLs take precedence over patterns.
+func (s *MockServer) findMock(req *http.Request, body []byte) (*Mock, map[string]string) {
+
+	originalURL := req.Header.Get("X-Original-URL")
+	key, query := mockKey(req.Method, originalURL)
+
+	exact := s.matchMock(s.mocks[key], req, query, body)
+	if exact != nil && !exact.generated {
+		return exact, nil
+	}
+
+	reqURL, err := url.Parse(originalURL)
+	if err != nil {
+		return exact, nil
+	}
+
+	reqURL.Fragment = ""
+	reqURL.RawFragment = ""
+
+	var match *Mock
+	var matchVars map[string]string
+	best := -1
+
+	for _, m := range s.patterns {
+
+		if m.HTTPMethod != req.Method {
+			continue
+		}
+
+		vars, ok := m.pattern.match(reqURL)
+		if !ok {
+			continue
+		}
+
+		// The query is part of regular expressions
+		q := query
+		if m.pattern.withQuery {
+			q = m.query
+		}
+
+		if specificity, ok := m.match(req, q, body, s.scenarioState); ok && preferred(m, specificity, match, best) {
+			match, matchVars, best = m, vars, specificity
+		}
+	}
+
+	// Generated Mocks are the last resort
+	if exact != nil && (match == nil || match.generated) {
+		return exact, nil
+	}
+
+	return match, matchVars
+}
+
+// matchMock returns the most specific Mock matching the request, if any.
+func (s *MockServer) matchMock(mocks []*Mock, req *http.Request, query url.Values, body []byte) *Mock {
+
+	var match *Mock
+	best := -1
+
+	for _, m := range mocks {
+		if specificity, ok := m.match(req, query, body, s.scenarioState); ok && preferred(m, specificity, match, best) {
+			match, best = m, specificity
+		}
+	}
+
+	return match
+}
+
+// preferred tells if a Mock matching a request, with the given specificity,
+// is preferred over the current match. Explicit Mocks are preferred over
+// generated ones, then the most specific, then the latest added.
+func preferred(m *Mock, specificity int, match *Mock, best int) bool {
+
+	switch {
+	case match == nil:
+		return true
+	case m.generated != match.generated:
+		return !m.generated
+	}
+
+	return specificity >= best
+}
+
+// match tells if the Mock matches the request, and how specific the Mock is:
+// the amount of request headers and body conditions it has.
+// scenarioState returns the current state of a Scenario.
+func (m *Mock) match(req *http.Request, query url.Values, body []byte, scenarioState func(string) string) (specificity int, ok bool) {
+
+	if !sameQuery(m.query, query) {
+		return 0, false
+	}
+
+	if m.OnExhausted == SequenceFail && len(m.Responses) > 0 && m.calls >= len(m.Responses) {
+		return 0, false
+	}
+
+	if m.RequiredState != "" {
+		if scenarioState(m.Scenario) != m.RequiredState {
+			return 0, false
+		}
+		specificity++
+	}
+
+	for k, values := range m.ReqHeaders {
+		for _, v := range values {
+			if !match(v, req.Header[textproto.CanonicalMIMEHeaderKey(k)]) {
+				return 0, false
+			}
+			specificity++
+		}
+	}
+
+	for _, k := range m.present {
+		if len(req.Header[textproto.CanonicalMIMEHeaderKey(k)]) == 0 {
+			return 0, false
+		}
+		specificity++
+	}
+
+	if m.ReqBody != "" {
+		if !m.ReqBodyMatch.equal([]byte(m.ReqBody), body) {
+			return 0, false
+		}
+		specificity++
+	}
+
+	if m.ReqBodyFunc != nil {
+		if !m.ReqBodyFunc(body) {
+			return 0, false
+		}
+		specificity++
+	}
+
+	return specificity, true
+}
+
+// use returns the response for the current call, and moves the Scenario
+// to its new state, if any.
+func (m *Mock) use(scenarios map[string]string) MockResponse {
+
+	if m.NewState != "" {
+		scenarios[m.Scenario] = m.NewState
+	}
+
+	i := m.calls
+	m.calls++
+
+	resp := MockResponse{HTTPCode: m.RespHTTPCode, Headers: m.RespHeaders, Body: m.RespBody}
+
+	if len(m.Responses) > 0 {
+
+		if i >= len(m.Responses) {
+			switch m.OnExhausted {
+			case SequenceCycle:
+				i %= len(m.Responses)
+			default:
+				i = len(m.Responses) - 1
+			}
+		}
+
+		resp = m.Responses[i]
+	}
+
+	if resp.Latency == nil {
+		resp.Latency = m.Latency
+	}
+
+	if resp.Fault == NoFault {
+		resp.Fault = m.Fault
+	}
+
+	return resp
+}
+
+type mockVarsKey struct{}
+
+// MockVars returns the variables captured by the URL pattern of the Mock
+// whose Responder is handling the request.
+func MockVars(req *http.Request) map[string]string {
+	vars, _ := req.Context().Value(mockVarsKey{}).(map[string]string)
+	return vars
+}
+
+// respond computes the response with the Responder, for the request as the
+// client sent it. Latency and Fault default to the ones of the Mock.
+// The Responder is called without holding the lock, so it could use the
+// MockServer.
+func (m *Mock) respond(req *http.Request, body []byte, vars map[string]string) MockResponse {
+
+	r := req.Clone(context.WithValue(req.Context(), mockVarsKey{}, vars))
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if u, err := url.Parse(req.Header.Get("X-Original-URL")); err == nil {
+		r.URL = u
+
+		// Paths of the requests sent straight to the server have no host
+		if u.Host != "" {
+			r.Host = u.Host
+		}
+	}
+
+	r.Header.Del("X-Original-URL")
+
+	resp := m.Responder(r)
+
+	if resp.HTTPCode == 0 {
+		resp.HTTPCode = http.StatusOK
+	}
+
+	if resp.Latency == nil {
+		resp.Latency = m.Latency
+	}
+
+	if resp.Fault == NoFault {
+		resp.Fault = m.Fault
+	}
+
+	return resp
+}
+
+func (bm BodyMatch) equal(expected []byte, body []byte) bool {
+
+	switch bm {
+	case BodyJSON:
+		var e, b interface{}
+
+		if json.Unmarshal(expected, &e) != nil || json.Unmarshal(body, &b) != nil {
+			return false
+		}
+
+		return reflect.DeepEqual(e, b)
+	}
+
+	return bytes.Equal(expected, body)
+}
+
+// sameQuery tells if both have the same parameters and values, in any order.
+func sameQuery(a url.Values, b url.Values) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, va := range a {
+		vb, ok := b[k]
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+
+		sa := append([]string(nil), va...)
+		sb := append([]string(nil), vb...)
+		sort.Strings(sa)
+		sort.Strings(sb)
+
+		if !reflect.DeepEqual(sa, sb) {
+			return false
+		}
+	}
+
+	return true
 }
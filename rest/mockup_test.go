@@ -2,6 +2,8 @@ package rest
 
 import (
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -25,9 +27,256 @@ func TestMockup(t *testing.T) {
 
 	AddMockups(&mock)
 
-	v := Get(myURL)
+	v := Get(myURL, WithHeader("Hello", "world"))
 	if v.String() != "foo" {
 		t.Fatal("Mockup Fail!")
 	}
 
 }
+
+func TestMockupMatchHeaders(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	myURL := "http://mytest.com/headers"
+
+	AddMockups(
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodGet,
+			ReqHeaders:   http.Header{"X-Version": {"2"}},
+			RespHTTPCode: http.StatusOK,
+			RespBody:     "v2",
+		},
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodGet,
+			ReqHeaders:   http.Header{"X-Version": {"2"}, "X-Beta": {"true"}},
+			RespHTTPCode: http.StatusOK,
+			RespBody:     "v2 beta",
+		},
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodGet,
+			RespHTTPCode: http.StatusOK,
+			RespBody:     "any",
+		},
+	)
+
+	if v := Get(myURL); v.String() != "any" {
+		t.Fatal("Mock without headers should match: " + v.String())
+	}
+
+	if v := Get(myURL, WithHeader("X-Version", "2")); v.String() != "v2" {
+		t.Fatal("Mock with headers should match: " + v.String())
+	}
+
+	if v := Get(myURL, WithHeader("X-Version", "2"), WithHeader("X-Beta", "true")); v.String() != "v2 beta" {
+		t.Fatal("Most specific Mock should match: " + v.String())
+	}
+}
+
+func TestMockupMatchQuery(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	AddMockups(&Mock{
+		URL:          "http://mytest.com/query?a=1&b=2&b=3",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "query",
+	})
+
+	if v := Get("http://mytest.com/query?b=3&a=1&b=2"); v.String() != "query" {
+		t.Fatal("Query in any order should match: " + v.String())
+	}
+
+	if v := Get("http://mytest.com/query?a=1&b=2"); v.StatusCode != StatusMockUnmatched {
+		t.Fatal("Different query should not match")
+	}
+}
+
+func TestMockupMatchBody(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	myURL := "http://mytest.com/body"
+
+	AddMockups(
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodPost,
+			ReqBody:      `{"name":"Hernan","id":1}`,
+			ReqBodyMatch: BodyJSON,
+			RespHTTPCode: http.StatusCreated,
+			RespBody:     "json",
+		},
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodPost,
+			ReqBody:      `"exact"`,
+			RespHTTPCode: http.StatusCreated,
+			RespBody:     "exact",
+		},
+		&Mock{
+			URL:          myURL,
+			HTTPMethod:   http.MethodPost,
+			ReqBodyFunc:  func(b []byte) bool { return strings.Contains(string(b), "Matilda") },
+			RespHTTPCode: http.StatusCreated,
+			RespBody:     "func",
+		},
+	)
+
+	if v := Post(myURL, &User{ID: 1, Name: "Hernan"}); v.String() != "json" {
+		t.Fatal("JSON body should match: " + v.String())
+	}
+
+	if v := Post(myURL, "exact"); v.String() != "exact" {
+		t.Fatal("Exact body should match: " + v.String())
+	}
+
+	if v := Post(myURL, &User{ID: 2, Name: "Matilda"}); v.String() != "func" {
+		t.Fatal("Body predicate should match: " + v.String())
+	}
+
+	if v := Post(myURL, &User{ID: 3, Name: "Juan"}); v.StatusCode != StatusMockUnmatched {
+		t.Fatal("Different body should not match")
+	}
+}
+
+func TestMockupOverride(t *testing.T) {
+
+	defer StopMockupServer()
+	StartMockupServer()
+	defer FlushMockups()
+
+	myURL := "http://mytest.com/override"
+
+	AddMockups(&Mock{URL: myURL, HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK, RespBody: "old"})
+	AddMockups(&Mock{URL: myURL, HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK, RespBody: "new"})
+
+	if v := Get(myURL); v.String() != "new" {
+		t.Fatal("Latest Mock should override: " + v.String())
+	}
+
+	if key, _ := mockKey(http.MethodGet, myURL); len(defaultMockServer.mocks[key]) != 1 {
+		t.Fatal("Overridden Mock should be removed")
+	}
+}
+
+func TestReplaceMockups(t *testing.T) {
+
+	ms := NewMockServer(t)
+	ms.AddMockups(
+		&Mock{URL: "http://mytest.com/replace/old", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK},
+		&Mock{URL: "http://mytest.com/replace/{id}", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK},
+	)
+
+	rb := &RequestBuilder{MockServer: ms, DisableCache: true}
+	rb.Get("http://mytest.com/replace/old")
+	ms.SetScenarioState("user", "created")
+
+	err := ms.ReplaceMockups(&Mock{URL: "http://mytest.com/replace/new", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := rb.Get("http://mytest.com/replace/old"); v.StatusCode != StatusMockUnmatched {
+		t.Fatal("Previous Mocks should be removed", v.StatusCode)
+	}
+
+	if v := rb.Get("http://mytest.com/replace/new"); v.StatusCode != http.StatusOK {
+		t.Fatal("New Mocks should be added", v.StatusCode)
+	}
+
+	if len(ms.MockCalls()) != 3 || ms.ScenarioState("user") != "created" {
+		t.Fatal("Calls and scenario states should be kept")
+	}
+
+	err = ms.ReplaceMockups(&Mock{URL: "^http://mytest.com/(", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK})
+	if v := rb.Get("http://mytest.com/replace/new"); err == nil || v.StatusCode != http.StatusOK {
+		t.Fatal("Previous Mocks should be kept if any pattern is invalid", err)
+	}
+}
+
+func TestMockServerParallel(t *testing.T) {
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		name := name
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ms := NewMockServer(t)
+			ms.AddMockups(&Mock{
+				URL:          "http://mytest.com/parallel",
+				HTTPMethod:   http.MethodGet,
+				RespHTTPCode: http.StatusOK,
+				RespHeaders:  http.Header{"Cache-Control": {"max-age=60"}},
+				RespBody:     name,
+			})
+
+			rb := &RequestBuilder{MockServer: ms}
+
+			for i := 0; i < 10; i++ {
+				if resp := rb.Get("http://mytest.com/parallel"); resp.String() != name {
+					t.Fatalf("Got %q, expected %q", resp.String(), name)
+				}
+			}
+
+			if len(ms.MockCalls()) != 1 {
+				t.Fatal("Other requests should be served from the cache")
+			}
+		})
+	}
+}
+
+func TestMockServerClosed(t *testing.T) {
+
+	ms := NewMockServer(nil)
+	ms.AddMockups(&Mock{URL: "http://mytest.com/closed", HTTPMethod: http.MethodGet, RespHTTPCode: http.StatusOK})
+	ms.Close()
+
+	rb := &RequestBuilder{MockServer: ms}
+
+	if resp := rb.Get("http://mytest.com/closed"); resp.Err == nil {
+		t.Fatal("Requests to a closed mockup server should fail")
+	}
+}
+
+func TestMockupEnvironmentVariable(t *testing.T) {
+
+	t.Setenv("RESTFUL_MOCK", "1")
+
+	mockEnvOnce = sync.Once{}
+	defer func() { mockEnvOnce = sync.Once{} }()
+	defer StopMockupServer()
+
+	if rb := new(RequestBuilder); rb.mockServer() != defaultMockServer || defaultMockServer.URL() == "" {
+		t.Fatal("RESTFUL_MOCK should start the mockup server")
+	}
+}
+
+func TestMockupServerConcurrentStart(t *testing.T) {
+
+	defer StopMockupServer()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			StartMockupServer()
+			Get("http://mytest.com/concurrent")
+		}()
+	}
+
+	wg.Wait()
+}
@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"encoding/xml"
-	"io/ioutil"
+	"errors"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -18,33 +18,78 @@ var contentVerbs = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
 var maxAge = regexp.MustCompile(`(?:max-age|s-maxage)=(\d+)`)
 var httpDateFormat = "Mon, 01 Jan 2006 15:04:05 GMT"
 
-func (rb *RequestBuilder) doRequest(verb string, reqURL string, reqBody interface{}) (response *Response) {
+func (rb *RequestBuilder) doRequest(verb string, reqURL string, reqBody interface{}, opts []RequestOption) (response *Response) {
 
 	var cacheURL string
 	var cacheResp *Response
 
 	response = new(Response)
-	reqURL = rb.BaseURL + reqURL
+	o := newReqOptions(opts)
+
+	defer func() {
+		response.success = rb.SuccessRange
+		response.errorPayload = rb.ErrorPayload
+
+		if rb.StatusErrors && response.Err == nil {
+			response.Err = response.Error()
+		}
+	}()
+
+	reqURL, err := rb.requestURL(reqURL, o)
+	if err != nil {
+		response.Err = err
+		return
+	}
+
+	ms := rb.mockServer()
+
+	// Serve the recorded responses from the mockup server
+	if rb.Cassette != nil && rb.Cassette.Mode == CassetteReplay {
+		if ms, err = rb.Cassette.replay(rb.MockServer); err != nil {
+			response.Err = err
+			return
+		}
+	}
+
+	//Equivalent URLs share the same cache entry
+	cacheKey := normalizeURL(reqURL)
+
+	//Each mockup server has its own cache entries
+	if ms != nil {
+		cacheKey = ms.URL() + " " + cacheKey
+	}
+
+	cc := rb.cacheControl(o)
+	useCache := !rb.DisableCache && !o.disableCache && !o.stream && !cc.NoStore && match(verb, readVerbs)
 
 	//If Cache enable && operation is read: Cache GET
-	if !rb.DisableCache && match(verb, readVerbs) {
-		if cacheResp = resourceCache.get(reqURL); cacheResp != nil {
-			cacheResp.cacheHit.Store(true)
+	if useCache {
+		if cacheResp = resourceCache.get(cacheKey); cacheResp != nil {
+			if !cc.NoCache && cacheResp.acceptable(cc) {
+				return cacheResp.hit()
+			}
+
+			// Not fresh enough, and it can't be revalidated either
 			if !cacheResp.revalidate {
-				return cacheResp
+				cacheResp = nil
 			}
 		}
 	}
 
+	// Nothing in the cache to be used, and we are not allowed to go upstream
+	if cc.OnlyIfCached {
+		return gatewayTimeout(verb, reqURL)
+	}
+
 	//Marshal request to JSON or XML
-	body, err := rb.marshalReqBody(reqBody)
+	body, err := rb.marshalReqBody(reqBody, rb.contentType(o))
 	if err != nil {
 		response.Err = err
 		return
 	}
 
 	// Change URL to point to Mockup server
-	reqURL, cacheURL, err = checkMockup(reqURL)
+	reqURL, cacheURL, err = checkMockup(reqURL, ms)
 	if err != nil {
 		response.Err = err
 		return
@@ -53,6 +98,24 @@ func (rb *RequestBuilder) doRequest(verb string, reqURL string, reqBody interfac
 	//Get Client (client + transport)
 	client := rb.getClient()
 
+	//Same client, with the time out of this request
+	if o.timeout != nil {
+		c := *client
+		c.Timeout = *o.timeout
+		client = &c
+	}
+
+	//Same client, trusting the TLS mockup server
+	if ms != nil {
+		if tr, ok := client.Transport.(*http.Transport); ok {
+			if mockTr := ms.transport(tr); mockTr != tr {
+				c := *client
+				c.Transport = mockTr
+				client = &c
+			}
+		}
+	}
+
 	//Create request
 	request, err := http.NewRequest(verb, reqURL, bytes.NewBuffer(body))
 	if err != nil {
@@ -61,26 +124,52 @@ func (rb *RequestBuilder) doRequest(verb string, reqURL string, reqBody interfac
 	}
 
 	// Set extra parameters
-	rb.setParams(client, request, cacheResp, cacheURL)
+	rb.setParams(client, request, o, cacheResp)
+
+	//If mockup
+	if ms != nil {
+		request.Header.Set("X-Original-URL", cacheURL)
+	}
+
+	// Make the request. Streams time out on the response headers only, as
+	// they are read by the caller
+	var httpResp *http.Response
+	if o.stream {
+		httpResp, err = doStream(client, request)
+	} else {
+		httpResp, err = client.Do(request)
+	}
 
-	// Make the request
-	httpResp, err := client.Do(request)
 	if err != nil {
 		response.Err = err
 		return
 	}
 
+	// Hand the body to the caller, unread
+	if o.stream {
+		rb.stream(response, httpResp, o.maxBodySize)
+		return
+	}
+
 	// Read response
 	defer httpResp.Body.Close()
-	respBody, err := ioutil.ReadAll(httpResp.Body)
+	respBody, err := readBody(httpResp, o.maxBodySize)
 	if err != nil {
 		response.Err = err
 		return
 	}
 
-	// If we get a 304, return response from cache
-	if httpResp.StatusCode == http.StatusNotModified {
-		response = cacheResp
+	if rb.Cassette != nil && rb.Cassette.Mode == CassetteRecord {
+		if err := rb.Cassette.record(request, cacheURL, body, httpResp, respBody); err != nil {
+			response.Err = err
+			return
+		}
+	}
+
+	// If we get a 304, return response from cache. Unless the caller
+	// revalidated its own copy
+	if httpResp.StatusCode == http.StatusNotModified && cacheResp != nil {
+		response = cacheResp.hit()
 		return
 	}
 
@@ -95,25 +184,46 @@ func (rb *RequestBuilder) doRequest(verb string, reqURL string, reqBody interfac
 		response.revalidate = true
 	}
 
-	//If Cache enable: Cache SETNX
-	if !rb.DisableCache && match(verb, readVerbs) && (ttl || lastModified || etag) {
-		resourceCache.setNX(cacheURL, response)
+	//If Cache enable: Cache SET
+	if useCache && (ttl || lastModified || etag) {
+		resourceCache.set(cacheKey, response)
 	}
 
 	return
 }
 
-func checkMockup(reqURL string) (string, string, error) {
+// requestURL builds the final URL of a request: the template expanded with
+// the request Params, joined to the BaseURL, plus the request query.
+func (rb *RequestBuilder) requestURL(reqURL string, o *reqOptions) (string, error) {
+
+	if o.params != nil {
+		expanded, err := expandURL(reqURL, o.params)
+		if err != nil {
+			return reqURL, err
+		}
+
+		reqURL = expanded
+	}
+
+	return o.withQuery(joinURL(rb.BaseURL, reqURL))
+}
+
+func checkMockup(reqURL string, ms *MockServer) (string, string, error) {
 
 	cacheURL := reqURL
 
-	if mockUpEnv {
+	if ms != nil {
 
 		rURL, err := url.Parse(reqURL)
 		if err != nil {
 			return reqURL, cacheURL, err
 		}
 
+		mockServerURL := ms.baseURL()
+		if mockServerURL == nil {
+			return reqURL, cacheURL, errors.New("Mockup server is closed")
+		}
+
 		rURL.Scheme = mockServerURL.Scheme
 		rURL.Host = mockServerURL.Host
 
@@ -123,10 +233,10 @@ func checkMockup(reqURL string) (string, string, error) {
 	return reqURL, cacheURL, nil
 }
 
-func (rb *RequestBuilder) marshalReqBody(body interface{}) (b []byte, err error) {
+func (rb *RequestBuilder) marshalReqBody(body interface{}, ctype ContentType) (b []byte, err error) {
 
 	if body != nil {
-		switch rb.ContentType {
+		switch ctype {
 		case JSON:
 			b, err = json.Marshal(body)
 		case XML:
@@ -164,6 +274,8 @@ func (rb *RequestBuilder) getClient() *http.Client {
 				}
 			}
 
+			tr.TLSClientConfig = cp.TLSClientConfig
+
 		}
 
 		rb.client = &http.Client{Transport: tr}
@@ -190,25 +302,24 @@ func (rb *RequestBuilder) getTimeout() time.Duration {
 
 }
 
-func (rb *RequestBuilder) setParams(client *http.Client, req *http.Request, cacheResp *Response, cacheURL string) {
+func (rb *RequestBuilder) setParams(client *http.Client, req *http.Request, o *reqOptions, cacheResp *Response) {
 
 	//Custom Headers
+	//Each request gets its own copy, as the RequestBuilder is shared
 	if rb.Headers != nil {
-		req.Header = rb.Headers
+		req.Header = rb.Headers.Clone()
 	}
 
-	//Default headers
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Cache-Control", "no-cache")
-
-	//If mockup
-	if mockUpEnv {
-		req.Header.Set("X-Original-URL", cacheURL)
+	//Cache directives
+	if rb.CacheControl != nil {
+		if cc := rb.CacheControl.String(); cc != "" {
+			req.Header.Set("Cache-Control", cc)
+		}
 	}
 
 	// Basic Auth
-	if rb.BasicAuth != nil {
-		req.SetBasicAuth(rb.BasicAuth.UserName, rb.BasicAuth.Password)
+	if ba := rb.basicAuth(o); ba != nil {
+		req.SetBasicAuth(ba.UserName, ba.Password)
 	}
 
 	// User Agent
@@ -222,7 +333,7 @@ func (rb *RequestBuilder) setParams(client *http.Client, req *http.Request, cach
 	//Encoding
 	var cType string
 
-	switch rb.ContentType {
+	switch rb.contentType(o) {
 	case JSON:
 		cType = "json"
 	case XML:
@@ -235,6 +346,11 @@ func (rb *RequestBuilder) setParams(client *http.Client, req *http.Request, cach
 		req.Header.Set("Content-Type", "application/"+cType)
 	}
 
+	//Headers for this request only
+	for k, v := range o.headers {
+		req.Header[k] = v
+	}
+
 	if cacheResp != nil && cacheResp.revalidate {
 		switch {
 		case cacheResp.etag != "":
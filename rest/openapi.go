@@ -0,0 +1,592 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operations of an OpenAPI path item, as HTTP Methods.
+var openAPIMethods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+	http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace,
+}
+
+// Deepest schema synthesized, so recursive schemas end.
+const maxSchemaDepth = 8
+
+// openAPISpec is an OpenAPI 3 document, as unmarshalled from JSON or YAML.
+type openAPISpec struct {
+	doc map[string]interface{}
+}
+
+// openAPIOperation is an operation of an OpenAPI document.
+type openAPIOperation struct {
+	method string
+	path   string
+
+	// Parameters of the path item and the operation
+	params []map[string]interface{}
+	op     map[string]interface{}
+}
+
+// readOpenAPI reads an OpenAPI 3 document, JSON or YAML.
+func readOpenAPI(path string) (*openAPISpec, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+
+	if isYAMLFile(path) {
+		items, err := parseYAML(data)
+		if err != nil {
+			yerr := err.(*yamlError)
+			return nil, &FixtureError{path, yerr.line, yerr}
+		}
+
+		if len(items) != 1 {
+			return nil, &FixtureError{File: path, Err: errors.New("expected one YAML document")}
+		}
+
+		doc = items[0].value
+
+	} else if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, &FixtureError{path, jsonErrorLine(data, 0, err), err}
+	}
+
+	m, ok := doc.(map[string]interface{})
+	if !ok || m["paths"] == nil {
+		return nil, &FixtureError{File: path, Err: errors.New("not an OpenAPI document")}
+	}
+
+	return &openAPISpec{doc: m}, nil
+}
+
+// baseURL returns the URL of the first server of the document, which must
+// be absolute.
+func (spec *openAPISpec) baseURL() (string, error) {
+
+	servers := asSlice(spec.doc["servers"])
+	if len(servers) == 0 {
+		return "", errors.New("OpenAPI: no servers, a base URL is required")
+	}
+
+	base := asString(asMap(servers[0])["url"])
+
+	if u, err := url.Parse(base); err != nil || !u.IsAbs() {
+		return "", errors.New("OpenAPI: the server URL " + base + " is not absolute, a base URL is required")
+	}
+
+	return base, nil
+}
+
+// operations returns the operations of the document, sorted by path.
+func (spec *openAPISpec) operations() []openAPIOperation {
+
+	paths := asMap(spec.doc["paths"])
+
+	var sorted []string
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var ops []openAPIOperation
+
+	for _, path := range sorted {
+
+		item := spec.resolve(paths[path])
+
+		for _, method := range openAPIMethods {
+
+			op := spec.resolve(item[strings.ToLower(method)])
+			if op == nil {
+				continue
+			}
+
+			ops = append(ops, openAPIOperation{
+				method: method,
+				path:   path,
+				params: spec.params(item["parameters"], op["parameters"]),
+				op:     op,
+			})
+		}
+	}
+
+	return ops
+}
+
+// params merges the parameters of a path item and an operation, which
+// override the ones with the same name and location.
+func (spec *openAPISpec) params(itemParams interface{}, opParams interface{}) []map[string]interface{} {
+
+	var params []map[string]interface{}
+
+	for _, list := range []interface{}{itemParams, opParams} {
+	next:
+		for _, p := range asSlice(list) {
+			param := spec.resolve(p)
+
+			for i, old := range params {
+				if old["name"] == param["name"] && old["in"] == param["in"] {
+					params[i] = param
+					continue next
+				}
+			}
+
+			params = append(params, param)
+		}
+	}
+
+	return params
+}
+
+// resolve returns the object, following its references, if any.
+// Only references in the same document are supported.
+func (spec *openAPISpec) resolve(v interface{}) map[string]interface{} {
+
+	m := asMap(v)
+
+	for i := 0; i < maxSchemaDepth && m != nil; i++ {
+
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m
+		}
+
+		m = asMap(spec.pointer(ref))
+	}
+
+	return m
+}
+
+// pointer returns the value at a JSON pointer in the document, like
+// #/components/schemas/User
+func (spec *openAPISpec) pointer(ref string) interface{} {
+
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+
+	var v interface{} = spec.doc
+
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+
+	return v
+}
+
+// LoadOpenAPI generates Mocks for the operations of an OpenAPI document, in
+// the global mockup server. See MockServer.LoadOpenAPI
+func LoadOpenAPI(path string, baseURL string) error {
+	return defaultMockServer.LoadOpenAPI(path, baseURL)
+}
+
+// LoadOpenAPI generates Mocks for every operation of an OpenAPI 3 document,
+// JSON or YAML, whose URLs are the paths of the document joined to baseURL.
+// If baseURL is empty, the URL of the first server of the document is used.
+//
+// Each operation answers with its lowest 2xx response, and any other
+// response when requested with the Prefer header, whatever the query:
+//
+//	Prefer: code=404, example=missing
+//
+// Bodies are the examples of the document: the one named by the Prefer
+// header, or the first one by name. They are synthesized from the schemas
+// if the response has no examples.
+//
+// Explicit Mocks are always preferred over generated ones, so operations
+// could be overridden with AddMockups, before or after loading the document.
+func (s *MockServer) LoadOpenAPI(path string, baseURL string) error {
+
+	spec, err := readOpenAPI(path)
+	if err != nil {
+		return err
+	}
+
+	if baseURL == "" {
+		if baseURL, err = spec.baseURL(); err != nil {
+			return err
+		}
+	}
+
+	var mocks []*Mock
+
+	for _, op := range spec.operations() {
+		if m := spec.mock(op, baseURL); m != nil {
+			mocks = append(mocks, m)
+		}
+	}
+
+	return s.AddMockups(mocks...)
+}
+
+// mock generates the Mock of an operation, which answers with the default
+// response, or with the one requested with the Prefer header. Any query is
+// accepted.
+func (spec *openAPISpec) mock(op openAPIOperation, baseURL string) *Mock {
+
+	responses := asMap(op.op["responses"])
+
+	var codes []int
+	fallback := false
+
+	for code := range responses {
+		switch c, ok := parseStatusCode(code); {
+		case ok:
+			codes = append(codes, c)
+		case code == "default":
+			fallback = true
+		}
+	}
+
+	sort.Ints(codes)
+
+	// The default response is used when no status code is defined
+	if len(codes) == 0 && fallback {
+		codes = []int{http.StatusOK}
+	}
+
+	if len(codes) == 0 {
+		return nil
+	}
+
+	dflt := codes[0]
+	for _, c := range codes {
+		if c >= 200 && c < 300 {
+			dflt = c
+			break
+		}
+	}
+
+	byCode := make(map[int]MockResponse, len(codes))
+	examples := make(map[int]map[string]string, len(codes))
+
+	for _, code := range codes {
+
+		resp := spec.resolve(responses[statusKey(responses, code)])
+		if resp == nil {
+			resp = spec.resolve(responses["default"])
+		}
+
+		headers, body, named := spec.example(resp)
+		byCode[code] = MockResponse{HTTPCode: code, Headers: headers, Body: body}
+		examples[code] = named
+	}
+
+	return &Mock{
+		URL:        openAPIPattern(baseURL, op.path),
+		HTTPMethod: op.method,
+		Responder: func(req *http.Request) MockResponse {
+
+			code, example := preferences(req.Header)
+
+			if _, ok := byCode[code]; !ok {
+				code = dflt
+			}
+
+			resp := byCode[code]
+			if body, ok := examples[code][example]; ok {
+				resp.Body = body
+			}
+
+			return resp
+		},
+		generated: true,
+	}
+}
+
+var openAPIPathParam = regexp.MustCompile(`\{[^}/]*\}`)
+
+// openAPIPattern returns the regular expression of the URLs of a path,
+// with any query.
+func openAPIPattern(baseURL string, path string) string {
+
+	parts := openAPIPathParam.Split(joinURL(baseURL, path), -1)
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+
+	return "^" + strings.Join(parts, `([^/?]+)`) + `(\?.*)?$`
+}
+
+// preferences returns the status code and the name of the example
+// requested with the Prefer header, like code=404, example=missing. The
+// code is 0 and the example empty if they are not requested.
+func preferences(header http.Header) (code int, example string) {
+
+	for _, value := range header.Values("Prefer") {
+		for _, pref := range strings.Split(value, ",") {
+
+			if i := strings.Index(pref, ";"); i >= 0 {
+				pref = pref[:i]
+			}
+
+			name, v, ok := strings.Cut(strings.TrimSpace(pref), "=")
+			if !ok {
+				continue
+			}
+
+			v = strings.Trim(strings.TrimSpace(v), `"`)
+
+			switch name = strings.TrimSpace(name); {
+			case strings.EqualFold(name, "code") && code == 0:
+				code, _ = strconv.Atoi(v)
+			case strings.EqualFold(name, "example") && example == "":
+				example = v
+			}
+		}
+	}
+
+	return code, example
+}
+
+// parseStatusCode parses response keys like 404 or 4XX.
+func parseStatusCode(code string) (int, bool) {
+
+	if len(code) == 3 && strings.HasSuffix(strings.ToUpper(code), "XX") && code[0] >= '1' && code[0] <= '5' {
+		return int(code[0]-'0') * 100, true
+	}
+
+	c, err := strconv.Atoi(code)
+	if err != nil || c < 100 || c > 599 {
+		return 0, false
+	}
+
+	return c, true
+}
+
+// statusKey returns the key of a status code in responses: 404 or 4XX.
+func statusKey(responses map[string]interface{}, code int) string {
+
+	if key := strconv.Itoa(code); responses[key] != nil {
+		return key
+	}
+
+	return strconv.Itoa(code/100) + "XX"
+}
+
+// example returns the headers and body of a response: its JSON content if
+// any, otherwise its first content type. The bodies of its named examples
+// are returned as well.
+func (spec *openAPISpec) example(resp map[string]interface{}) (http.Header, string, map[string]string) {
+
+	content := asMap(resp["content"])
+	if len(content) == 0 {
+		return nil, "", nil
+	}
+
+	var types []string
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	ctype := types[0]
+	for _, t := range types {
+		if isJSONMediaType(t) {
+			ctype = t
+			break
+		}
+	}
+
+	media := spec.resolve(content[ctype])
+	header := http.Header{"Content-Type": {ctype}}
+
+	examples := asMap(media["examples"])
+
+	var names []string
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	named := make(map[string]string, len(names))
+	for _, name := range names {
+		named[name] = exampleBody(ctype, spec.resolve(examples[name])["value"])
+	}
+
+	var value interface{}
+
+	switch ex, ok := media["example"]; {
+	case ok:
+		value = ex
+	case len(names) > 0:
+		return header, named[names[0]], named
+	default:
+		value = spec.synthesize(media["schema"], 0)
+	}
+
+	return header, exampleBody(ctype, value), named
+}
+
+// exampleBody returns the body of an example, as JSON unless it is a string
+// of a content type other than JSON.
+func exampleBody(ctype string, value interface{}) string {
+
+	if s, ok := value.(string); ok && !isJSONMediaType(ctype) {
+		return s
+	}
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+
+	return string(body)
+}
+
+// synthesize builds a value valid for a schema, from its examples, defaults
+// and enumerations, or from its type.
+func (spec *openAPISpec) synthesize(schema interface{}, depth int) interface{} {
+
+	sc := spec.resolve(schema)
+	if sc == nil || depth > maxSchemaDepth {
+		return nil
+	}
+
+	for _, k := range []string{"example", "default", "const"} {
+		if v, ok := sc[k]; ok {
+			return v
+		}
+	}
+
+	if enum := asSlice(sc["enum"]); len(enum) > 0 {
+		return enum[0]
+	}
+
+	if examples := asSlice(sc["examples"]); len(examples) > 0 {
+		return examples[0]
+	}
+
+	if all := asSlice(sc["allOf"]); len(all) > 0 {
+		merged := make(map[string]interface{})
+
+		for _, s := range all {
+			for k, v := range asMap(spec.synthesize(s, depth+1)) {
+				merged[k] = v
+			}
+		}
+
+		return merged
+	}
+
+	for _, k := range []string{"oneOf", "anyOf"} {
+		if options := asSlice(sc[k]); len(options) > 0 {
+			return spec.synthesize(options[0], depth+1)
+		}
+	}
+
+	switch schemaType(sc) {
+
+	case "object":
+		obj := make(map[string]interface{})
+		for name, prop := range asMap(sc["properties"]) {
+			obj[name] = spec.synthesize(prop, depth+1)
+		}
+		return obj
+
+	case "array":
+		if depth == maxSchemaDepth {
+			return []interface{}{}
+		}
+		return []interface{}{spec.synthesize(sc["items"], depth+1)}
+
+	case "integer", "number":
+		if min, ok := sc["minimum"]; ok {
+			return min
+		}
+		return 0
+
+	case "boolean":
+		return true
+
+	case "string":
+		switch sc["format"] {
+		case "date":
+			return "2006-01-02"
+		case "date-time":
+			return "2006-01-02T15:04:05Z"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+
+	return nil
+}
+
+// schemaType returns the type of a schema. Types could be lists in
+// OpenAPI 3.1, and objects and arrays may have no type.
+func schemaType(sc map[string]interface{}) string {
+
+	switch t := sc["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+
+	switch {
+	case sc["properties"] != nil:
+		return "object"
+	case sc["items"] != nil:
+		return "array"
+	}
+
+	return ""
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+func asString(v interface{}) string {
+
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	}
+
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,193 @@
+package rest
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+const testOpenAPI = `
+openapi: 3.0.3
+servers:
+  - url: http://mytest.com/api
+paths:
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+    get:
+      responses:
+        "200":
+          description: A user
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+        "404":
+          description: Not found
+          content:
+            application/json:
+              examples:
+                missing:
+                  value: {"message": "not found"}
+                removed:
+                  value:
+                    message: removed
+    delete:
+      responses:
+        "204":
+          description: Deleted
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        id:
+          type: integer
+          minimum: 1
+        name:
+          type: string
+          example: Alice
+        role:
+          type: string
+          enum: [admin, user]
+`
+
+func TestLoadOpenAPI(t *testing.T) {
+
+	dir := writeFixtures(t, map[string]string{"api.yaml": testOpenAPI})
+
+	ms := NewMockServer(t)
+	ms.Strict(t)
+
+	if err := ms.LoadOpenAPI(filepath.Join(dir, "api.yaml"), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	rb := &RequestBuilder{MockServer: ms}
+
+	resp := rb.Get("http://mytest.com/api/users/1")
+	if resp.StatusCode != http.StatusOK || resp.String() != `{"id":1,"name":"Alice","role":"admin"}` {
+		t.Fatal("Wrong synthesized response", resp.StatusCode, resp.String())
+	}
+
+	if resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatal("Wrong Content-Type", resp.Header.Get("Content-Type"))
+	}
+
+	rb.Headers = http.Header{"Prefer": {"code=404"}}
+
+	resp = rb.Get("http://mytest.com/api/users/2")
+	if resp.StatusCode != http.StatusNotFound || resp.String() != `{"message":"not found"}` {
+		t.Fatal("Wrong example response", resp.StatusCode, resp.String())
+	}
+
+	rb.Headers = http.Header{"Prefer": {"return=minimal", "code=404; strict, example=missing"}}
+
+	resp = rb.Get("http://mytest.com/api/users/2")
+	if resp.StatusCode != http.StatusNotFound || resp.String() != `{"message":"not found"}` {
+		t.Fatal("The code of the Prefer header should be parsed", resp.StatusCode)
+	}
+
+	rb.Headers = http.Header{"Prefer": {"code=404, example=removed"}}
+
+	resp = rb.Get("http://mytest.com/api/users/2")
+	if resp.StatusCode != http.StatusNotFound || resp.String() != `{"message":"removed"}` {
+		t.Fatal("The example of the Prefer header should be used", resp.StatusCode, resp.String())
+	}
+
+	rb.Headers = http.Header{"Prefer": {"example=removed"}}
+
+	resp = rb.Get("http://mytest.com/api/users/2")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("Unknown examples should be ignored", resp.StatusCode, resp.String())
+	}
+
+	rb.Headers = nil
+
+	if resp = rb.Get("http://mytest.com/api/users/1?fields=x"); resp.StatusCode != http.StatusOK {
+		t.Fatal("Any query should be accepted", resp.StatusCode)
+	}
+
+	if resp = rb.Delete("http://mytest.com/api/users/1"); resp.StatusCode != http.StatusNoContent {
+		t.Fatal("Wrong status code", resp.StatusCode)
+	}
+}
+
+func TestPreferences(t *testing.T) {
+
+	type prefs struct {
+		code    int
+		example string
+	}
+
+	tests := map[string]prefs{
+		"code=404":                      {404, ""},
+		`code="503"`:                    {503, ""},
+		"return=minimal, Code=409":      {409, ""},
+		"code=500; strict, wait=10":     {500, ""},
+		"example=missing":               {0, "missing"},
+		`code=404, Example="not-found"`: {404, "not-found"},
+		"code=abc":                      {0, ""},
+	}
+
+	for prefer, expected := range tests {
+		code, example := preferences(http.Header{"Prefer": {prefer}})
+		if code != expected.code || example != expected.example {
+			t.Fatalf("%s: expected %v, got %d %q", prefer, expected, code, example)
+		}
+	}
+}
+
+func TestLoadOpenAPIOverride(t *testing.T) {
+
+	dir := writeFixtures(t, map[string]string{"api.yaml": testOpenAPI})
+
+	ms := NewMockServer(t)
+
+	ms.AddMockups(&Mock{
+		URL:          "http://localhost/users/7",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     `{"id":7}`,
+	})
+
+	if err := ms.LoadOpenAPI(filepath.Join(dir, "api.yaml"), "http://localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	rb := &RequestBuilder{MockServer: ms}
+
+	if resp := rb.Get("http://localhost/users/7"); resp.String() != `{"id":7}` {
+		t.Fatal("Explicit Mocks should be preferred", resp.String())
+	}
+
+	if resp := rb.Get("http://localhost/users/8"); resp.StatusCode != http.StatusOK || resp.String() == `{"id":7}` {
+		t.Fatal("Wrong generated response", resp.StatusCode, resp.String())
+	}
+
+	if resp := rb.Get("http://localhost/users/8/x"); resp.StatusCode != StatusMockUnmatched {
+		t.Fatal("Path parameters should be one segment", resp.StatusCode)
+	}
+}
+
+func TestLoadOpenAPIErrors(t *testing.T) {
+
+	dir := writeFixtures(t, map[string]string{
+		"noserver.json": `{"openapi": "3.0.3", "paths": {}}`,
+		"notspec.json":  `{"hello": "world"}`,
+	})
+
+	ms := NewMockServer(t)
+
+	if err := ms.LoadOpenAPI(filepath.Join(dir, "noserver.json"), ""); err == nil {
+		t.Fatal("A base URL should be required")
+	}
+
+	if err := ms.LoadOpenAPI(filepath.Join(dir, "notspec.json"), "http://localhost"); err == nil {
+		t.Fatal("Documents without paths should fail")
+	}
+}
@@ -0,0 +1,574 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// Request headers which OpenAPI describes apart, so their parameters are
+// ignored.
+var openAPIIgnoredHeaders = []string{"Accept", "Content-Type", "Authorization"}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// openAPIContract validates requests against the operations of an OpenAPI
+// document.
+type openAPIContract struct {
+	spec   *openAPISpec
+	base   string
+	routes []openAPIRoute
+
+	// Test failed by the violations
+	t testing.TB
+}
+
+// openAPIRoute is an operation, and the pattern of its URL.
+type openAPIRoute struct {
+	openAPIOperation
+	pattern *mockPattern
+	vars    int
+}
+
+// ValidateOpenAPI validates the requests received by the global mockup
+// server against an OpenAPI document. See MockServer.ValidateOpenAPI
+func ValidateOpenAPI(t testing.TB, path string, baseURL string) {
+	t.Helper()
+	defaultMockServer.ValidateOpenAPI(t, path, baseURL)
+}
+
+// ValidateOpenAPI validates the requests received by the MockServer against
+// an OpenAPI 3 document, JSON or YAML, until the test finishes. Requests to
+// URLs under baseURL, or under the first server of the document if empty,
+// must be operations of the document, and their path parameters, query
+// parameters, headers and JSON bodies must be valid for it. Otherwise the
+// test fails, with the path of each invalid field:
+//
+//	POST http://api.com/users violates the OpenAPI contract:
+//		body.age: got string, expected integer
+//
+// Violations are in the Violations of each MockCall as well. Requests are
+// answered by the Mocks anyway.
+func (s *MockServer) ValidateOpenAPI(t testing.TB, path string, baseURL string) {
+	t.Helper()
+
+	spec, err := readOpenAPI(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if baseURL == "" {
+		if baseURL, err = spec.baseURL(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	contract := &openAPIContract{spec: spec, base: strings.TrimRight(baseURL, "/"), t: t}
+
+	for _, op := range spec.operations() {
+		pattern, err := compileMockPattern(joinURL(baseURL, op.path))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		contract.routes = append(contract.routes, openAPIRoute{
+			openAPIOperation: op,
+			pattern:          pattern,
+			vars:             strings.Count(op.path, "{"),
+		})
+	}
+
+	// Concrete paths are matched before templated ones
+	sort.SliceStable(contract.routes, func(i, j int) bool {
+		return contract.routes[i].vars < contract.routes[j].vars
+	})
+
+	s.mtx.Lock()
+	s.contract = contract
+	s.mtx.Unlock()
+
+	t.Cleanup(func() {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		if s.contract == contract {
+			s.contract = nil
+		}
+	})
+}
+
+// validate returns the violations of the contract by the request, and fails
+// the test if any. s.mtx must be held.
+func (s *MockServer) validate(req *http.Request, body []byte) []string {
+
+	if s.contract == nil {
+		return nil
+	}
+
+	originalURL := req.Header.Get("X-Original-URL")
+
+	violations := s.contract.validate(req, originalURL, body)
+	if len(violations) > 0 {
+		s.contract.t.Errorf("%s %s violates the OpenAPI contract:\n\t%s",
+			req.Method, originalURL, strings.Join(violations, "\n\t"))
+	}
+
+	return violations
+}
+
+func (c *openAPIContract) validate(req *http.Request, originalURL string, body []byte) []string {
+
+	reqURL, err := url.Parse(originalURL)
+	if err != nil {
+		return nil
+	}
+
+	reqURL.Fragment = ""
+	reqURL.RawFragment = ""
+
+	// Requests to other APIs are not validated
+	path := strings.SplitN(originalURL, "?", 2)[0]
+	if path != c.base && !strings.HasPrefix(path, c.base+"/") {
+		return nil
+	}
+
+	var allowed []string
+	var route *openAPIRoute
+	var vars map[string]string
+	matched := -1
+
+	for i, r := range c.routes {
+
+		v, ok := r.pattern.match(reqURL)
+		if !ok {
+			continue
+		}
+
+		// Once a path matches, paths with more variables are not tried
+		if matched >= 0 && r.vars > matched {
+			break
+		}
+
+		matched = r.vars
+		allowed = append(allowed, r.method)
+
+		if r.method == req.Method && route == nil {
+			route, vars = &c.routes[i], v
+		}
+	}
+
+	switch {
+	case len(allowed) == 0:
+		return []string{"path: " + strings.TrimPrefix(path, c.base) + " is not an operation"}
+	case route == nil:
+		return []string{fmt.Sprintf("method: %s is not allowed, expected %s", req.Method, strings.Join(allowed, ", "))}
+	}
+
+	var violations []string
+
+	for _, p := range route.params {
+
+		name := asString(p["name"])
+		required := p["required"] == true
+
+		var values []string
+		var present bool
+
+		switch p["in"] {
+		case "path":
+			var v string
+			if v, present = vars[name]; present {
+				v, _ = url.PathUnescape(v)
+				values = []string{v}
+			}
+			required = true
+
+		case "query":
+			values, present = reqURL.Query()[name]
+
+		case "header":
+			if match(name, openAPIIgnoredHeaders) {
+				continue
+			}
+			values = req.Header.Values(name)
+			present = len(values) > 0
+
+		default:
+			continue
+		}
+
+		field := asString(p["in"]) + "." + name
+
+		if !present {
+			if required {
+				violations = append(violations, field+": required")
+			}
+			continue
+		}
+
+		violations = append(violations, c.validateParam(p, field, values)...)
+	}
+
+	return append(violations, c.validateBody(route.op["requestBody"], req.Header.Get("Content-Type"), body)...)
+}
+
+// validateParam validates the values of a parameter, converted to the type
+// of its schema.
+func (c *openAPIContract) validateParam(p map[string]interface{}, field string, values []string) []string {
+
+	schema := c.spec.resolve(p["schema"])
+	if schema == nil {
+		return nil
+	}
+
+	if schemaType(schema) != "array" {
+		return c.spec.validateSchema(schema, paramValue(schema, values[0]), field, 0)
+	}
+
+	// Arrays are repeated in queries, and comma separated otherwise
+	if p["in"] != "query" || p["explode"] == false {
+		values = strings.Split(values[0], ",")
+	}
+
+	items := c.spec.resolve(schema["items"])
+
+	array := make([]interface{}, len(values))
+	for i, v := range values {
+		array[i] = paramValue(items, v)
+	}
+
+	return c.spec.validateSchema(schema, array, field, 0)
+}
+
+// paramValue converts a parameter to the type of its schema, if possible.
+func paramValue(schema map[string]interface{}, v string) interface{} {
+
+	switch schemaType(schema) {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+
+	return v
+}
+
+// validateBody validates the request body against the request body of the
+// operation. Only JSON bodies are validated against their schema.
+func (c *openAPIContract) validateBody(requestBody interface{}, contentType string, body []byte) []string {
+
+	rb := c.spec.resolve(requestBody)
+
+	if len(body) == 0 {
+		if rb != nil && rb["required"] == true {
+			return []string{"body: required"}
+		}
+		return nil
+	}
+
+	if rb == nil {
+		return []string{"body: not expected"}
+	}
+
+	content := asMap(rb["content"])
+	if len(content) == 0 {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	var media map[string]interface{}
+	var types []string
+
+	for t, m := range content {
+		types = append(types, t)
+
+		if mediaTypeMatch(t, mediaType) && (media == nil || !strings.Contains(t, "*")) {
+			media = c.spec.resolve(m)
+		}
+	}
+
+	if media == nil {
+		sort.Strings(types)
+		return []string{fmt.Sprintf("header.Content-Type: got %q, expected %s", contentType, strings.Join(types, ", "))}
+	}
+
+	if !isJSONMediaType(mediaType) {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []string{"body: invalid JSON, " + err.Error()}
+	}
+
+	return c.spec.validateSchema(media["schema"], value, "body", 0)
+}
+
+// mediaTypeMatch tells if a media type matches a media range, like
+// application/* or */*
+func mediaTypeMatch(mediaRange string, mediaType string) bool {
+
+	mediaRange, _, _ = mime.ParseMediaType(mediaRange)
+
+	switch {
+	case mediaRange == "*/*" || mediaRange == mediaType:
+		return true
+	case strings.HasSuffix(mediaRange, "/*"):
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))
+	}
+
+	return false
+}
+
+// validateSchema returns the violations of the schema by the value, each
+// prefixed by the path of the invalid field.
+func (spec *openAPISpec) validateSchema(schema interface{}, v interface{}, field string, depth int) []string {
+
+	sc := spec.resolve(schema)
+	if sc == nil || depth > maxSchemaDepth {
+		return nil
+	}
+
+	var violations []string
+
+	add := func(format string, args ...interface{}) {
+		violations = append(violations, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	for _, s := range asSlice(sc["allOf"]) {
+		violations = append(violations, spec.validateSchema(s, v, field, depth+1)...)
+	}
+
+	for _, k := range []string{"anyOf", "oneOf"} {
+
+		options := asSlice(sc[k])
+		if len(options) == 0 {
+			continue
+		}
+
+		valid := 0
+		for _, s := range options {
+			if len(spec.validateSchema(s, v, field, depth+1)) == 0 {
+				valid++
+			}
+		}
+
+		switch {
+		case valid == 0:
+			add("doesn't match any schema of %s", k)
+		case valid > 1 && k == "oneOf":
+			add("matches %d schemas of oneOf, expected one", valid)
+		}
+	}
+
+	if v == nil {
+		if sc["nullable"] == true || schemaType(sc) == "" || allowsNull(sc) {
+			return violations
+		}
+
+		add("got null, expected %s", schemaType(sc))
+		return violations
+	}
+
+	if enum := asSlice(sc["enum"]); len(enum) > 0 && !inEnum(v, enum) {
+		add("got %v, expected one of %v", jsonString(v), jsonString(enum))
+	}
+
+	typ := schemaType(sc)
+
+	if got := jsonType(v); typ != "" && got != typ && !(typ == "number" && got == "integer") {
+		add("got %s, expected %s", got, typ)
+		return violations
+	}
+
+	switch value := v.(type) {
+
+	case string:
+		length := utf8.RuneCountInString(value)
+
+		if min, ok := toFloat(sc["minLength"]); ok && float64(length) < min {
+			add("length %d, expected at least %v", length, min)
+		}
+		if max, ok := toFloat(sc["maxLength"]); ok && float64(length) > max {
+			add("length %d, expected at most %v", length, max)
+		}
+		if pattern, ok := sc["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(value) {
+				add("%q doesn't match %s", value, pattern)
+			}
+		}
+		if format, ok := sc["format"].(string); ok && !validFormat(format, value) {
+			add("%q is not a valid %s", value, format)
+		}
+
+	case float64:
+		violations = append(violations, validateRange(sc, value, field)...)
+
+	case []interface{}:
+		if min, ok := toFloat(sc["minItems"]); ok && float64(len(value)) < min {
+			add("%d items, expected at least %v", len(value), min)
+		}
+		if max, ok := toFloat(sc["maxItems"]); ok && float64(len(value)) > max {
+			add("%d items, expected at most %v", len(value), max)
+		}
+		for i, item := range value {
+			violations = append(violations, spec.validateSchema(sc["items"], item, fmt.Sprintf("%s[%d]", field, i), depth+1)...)
+		}
+
+	case map[string]interface{}:
+		properties := asMap(sc["properties"])
+
+		for _, name := range asSlice(sc["required"]) {
+			n := asString(name)
+			if _, ok := value[n]; !ok && spec.resolve(properties[n])["readOnly"] != true {
+				violations = append(violations, field+"."+n+": required")
+			}
+		}
+
+		var names []string
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			prop, ok := properties[name]
+
+			switch {
+			case ok:
+				violations = append(violations, spec.validateSchema(prop, value[name], field+"."+name, depth+1)...)
+			case sc["additionalProperties"] == false:
+				violations = append(violations, field+"."+name+": not allowed")
+			default:
+				violations = append(violations, spec.validateSchema(sc["additionalProperties"], value[name], field+"."+name, depth+1)...)
+			}
+		}
+	}
+
+	return violations
+}
+
+// validateRange validates a number against the minimum and maximum of the
+// schema. Exclusive bounds are booleans in OpenAPI 3.0, and numbers in 3.1
+func validateRange(sc map[string]interface{}, n float64, field string) []string {
+
+	var violations []string
+
+	if min, ok := toFloat(sc["minimum"]); ok {
+		if sc["exclusiveMinimum"] == true && n <= min || n < min {
+			violations = append(violations, fmt.Sprintf("%s: got %v, expected more than %v", field, n, min))
+		}
+	}
+	if min, ok := toFloat(sc["exclusiveMinimum"]); ok && n <= min {
+		violations = append(violations, fmt.Sprintf("%s: got %v, expected more than %v", field, n, min))
+	}
+
+	if max, ok := toFloat(sc["maximum"]); ok {
+		if sc["exclusiveMaximum"] == true && n >= max || n > max {
+			violations = append(violations, fmt.Sprintf("%s: got %v, expected less than %v", field, n, max))
+		}
+	}
+	if max, ok := toFloat(sc["exclusiveMaximum"]); ok && n >= max {
+		violations = append(violations, fmt.Sprintf("%s: got %v, expected less than %v", field, n, max))
+	}
+
+	return violations
+}
+
+// jsonType returns the schema type of a value, as unmarshalled from JSON.
+func jsonType(v interface{}) string {
+
+	switch value := v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == float64(int64(value)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return "null"
+}
+
+func allowsNull(sc map[string]interface{}) bool {
+
+	for _, t := range asSlice(sc["type"]) {
+		if t == "null" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func inEnum(v interface{}, enum []interface{}) bool {
+
+	for _, e := range enum {
+		if n, ok := toFloat(e); ok {
+			if f, ok := v.(float64); ok && f == n {
+				return true
+			}
+			continue
+		}
+
+		if reflect.DeepEqual(v, e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validFormat(format string, s string) bool {
+
+	var err error
+
+	switch format {
+	case "date":
+		_, err = time.Parse("2006-01-02", s)
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, s)
+	case "uuid":
+		return uuidRegexp.MatchString(s)
+	}
+
+	return err == nil
+}
+
+// toFloat returns v, if it is a number of the document.
+func toFloat(v interface{}) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
+
+func jsonString(v interface{}) string {
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,201 @@
+package rest
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testContract = `{
+	"openapi": "3.0.3",
+	"servers": [
+		{
+			"url": "http://mytest.com/api"
+		}
+	],
+	"paths": {
+		"/users": {
+			"get": {
+				"parameters": [
+					{
+						"name": "limit",
+						"in": "query",
+						"schema": {
+							"type": "integer",
+							"maximum": 100
+						}
+					},
+					{
+						"name": "X-Tenant",
+						"in": "header",
+						"required": true,
+						"schema": {
+							"type": "string"
+						}
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "Users"
+					}
+				}
+			},
+			"post": {
+				"requestBody": {
+					"required": true,
+					"content": {
+						"application/json": {
+							"schema": {
+								"$ref": "#/components/schemas/User"
+							}
+						}
+					}
+				},
+				"responses": {
+					"201": {
+						"description": "Created"
+					}
+				}
+			}
+		},
+		"/users/{id}": {
+			"get": {
+				"parameters": [
+					{
+						"name": "id",
+						"in": "path",
+						"required": true,
+						"schema": {
+							"type": "integer"
+						}
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "A user"
+					}
+				}
+			}
+		}
+	},
+	"components": {
+		"schemas": {
+			"User": {
+				"type": "object",
+				"required": [
+					"name",
+					"address"
+				],
+				"properties": {
+					"id": {
+						"type": "integer",
+						"readOnly": true
+					},
+					"name": {
+						"type": "string",
+						"minLength": 1
+					},
+					"address": {
+						"type": "object",
+						"required": [
+							"city"
+						],
+						"properties": {
+							"city": {
+								"type": "string"
+							}
+						}
+					},
+					"tags": {
+						"type": "array",
+						"items": {
+							"type": "string",
+							"enum": [
+								"admin",
+								"user"
+							]
+						}
+					}
+				}
+			}
+		}
+	}
+}
+`
+
+func TestValidateOpenAPI(t *testing.T) {
+
+	dir := writeFixtures(t, map[string]string{"api.json": testContract})
+
+	ms := NewMockServer(t)
+	rec := &recordingTB{TB: t}
+	ms.ValidateOpenAPI(rec, filepath.Join(dir, "api.json"), "")
+
+	rb := &RequestBuilder{MockServer: ms}
+
+	tests := []struct {
+		name       string
+		resp       func() *Response
+		violations []string
+	}{
+		{"valid get", func() *Response {
+			return rb.Get("http://mytest.com/api/users?limit=10", WithHeader("X-Tenant", "a"))
+		}, nil},
+		{"valid post", func() *Response {
+			return rb.Post("http://mytest.com/api/users", map[string]interface{}{
+				"name": "Alice", "address": map[string]string{"city": "Rosario"}, "tags": []string{"admin"},
+			})
+		}, nil},
+		{"query and header", func() *Response {
+			return rb.Get("http://mytest.com/api/users?limit=500")
+		}, []string{"query.limit: got 500, expected less than 100", "header.X-Tenant: required"}},
+		{"path", func() *Response {
+			return rb.Get("http://mytest.com/api/users/abc")
+		}, []string{"path.id: got string, expected integer"}},
+		{"body", func() *Response {
+			return rb.Post("http://mytest.com/api/users", map[string]interface{}{
+				"name": 1, "address": map[string]string{}, "tags": []string{"root"},
+			})
+		}, []string{
+			"body.address.city: required",
+			"body.name: got integer, expected string",
+			`body.tags[0]: got "root", expected one of ["admin","user"]`,
+		}},
+		{"method", func() *Response {
+			return rb.Delete("http://mytest.com/api/users")
+		}, []string{"method: DELETE is not allowed, expected GET, POST"}},
+		{"operation", func() *Response {
+			return rb.Get("http://mytest.com/api/groups")
+		}, []string{"path: /groups is not an operation"}},
+		{"other api", func() *Response {
+			return rb.Get("http://other.com/groups")
+		}, nil},
+	}
+
+	for _, tt := range tests {
+
+		ms.FlushMockups()
+		rec.errors = nil
+
+		tt.resp()
+
+		calls := ms.MockCalls()
+		if len(calls) != 1 || !reflect.DeepEqual(calls[0].Violations, tt.violations) {
+			t.Fatalf("%s: wrong violations %q", tt.name, calls[0].Violations)
+		}
+
+		if (len(rec.errors) > 0) != (len(tt.violations) > 0) {
+			t.Fatalf("%s: wrong test errors %q", tt.name, rec.errors)
+		}
+	}
+
+	for _, cleanup := range rec.cleanups {
+		cleanup()
+	}
+
+	rb.Get("http://mytest.com/api/groups")
+
+	if calls := ms.MockCalls(); calls[len(calls)-1].Violations != nil {
+		t.Fatal("Requests should not be validated once the test finished")
+	}
+}
@@ -0,0 +1,160 @@
+package rest
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RequestOption customizes a single request, without changing the
+// RequestBuilder used to issue it.
+//
+//	resp := rb.Get("/user/1", rest.WithHeader("X-Request-Id", "1234"))
+type RequestOption interface {
+	apply(*reqOptions)
+}
+
+type optionFunc func(*reqOptions)
+
+func (f optionFunc) apply(o *reqOptions) {
+	f(o)
+}
+
+// reqOptions holds the settings of a single request.
+type reqOptions struct {
+	headers      http.Header
+	query        url.Values
+	timeout      *time.Duration
+	disableCache bool
+	contentType  *ContentType
+	basicAuth    *BasicAuth
+	params       Params
+	maxBodySize  int64
+	stream       bool
+}
+
+func newReqOptions(opts []RequestOption) *reqOptions {
+
+	o := new(reqOptions)
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(o)
+		}
+	}
+
+	return o
+}
+
+// WithHeader adds a header to a single request.
+// It overrides any header with the same key set by the RequestBuilder,
+// including the default ones, like User-Agent or Accept.
+// Using it many times with the same key sends all the values.
+func WithHeader(key, value string) RequestOption {
+	return optionFunc(func(o *reqOptions) {
+		if o.headers == nil {
+			o.headers = make(http.Header)
+		}
+
+		o.headers.Add(key, value)
+	})
+}
+
+// WithQuery adds a query parameter to the URL of a single request.
+// Using it many times with the same key sends all the values.
+func WithQuery(key, value string) RequestOption {
+	return optionFunc(func(o *reqOptions) {
+		if o.query == nil {
+			o.query = make(url.Values)
+		}
+
+		o.query.Add(key, value)
+	})
+}
+
+// WithTimeout sets the complete request time out of a single request,
+// overriding the one of the RequestBuilder. A zero timeout means no timeout.
+//
+// The RequestBuilder client and connection pool are still used.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return optionFunc(func(o *reqOptions) {
+		o.timeout = &timeout
+	})
+}
+
+// WithoutCache prevents a single request from being served from the cache,
+// and its Response from being cached.
+func WithoutCache() RequestOption {
+	return optionFunc(func(o *reqOptions) {
+		o.disableCache = true
+	})
+}
+
+// WithMaxBodySize limits the response body of a single request to n bytes.
+// Longer bodies fail with ErrBodyTooLarge: when read, if streamed. A zero
+// or negative n means no limit.
+func WithMaxBodySize(n int64) RequestOption {
+	return optionFunc(func(o *reqOptions) {
+		o.maxBodySize = n
+	})
+}
+
+// WithContentType sets the ContentType of a single request, overriding the
+// one of the RequestBuilder.
+// It is used for marshalling the body, and for the Accept and Content-Type
+// headers.
+func WithContentType(ctype ContentType) RequestOption {
+	return optionFunc(func(o *reqOptions) {
+		o.contentType = &ctype
+	})
+}
+
+// WithBasicAuth sets Basic Auth for a single request, overriding the one of
+// the RequestBuilder.
+func WithBasicAuth(userName, password string) RequestOption {
+	return optionFunc(func(o *reqOptions) {
+		o.basicAuth = &BasicAuth{UserName: userName, Password: password}
+	})
+}
+
+// contentType returns the ContentType of the request.
+func (rb *RequestBuilder) contentType(o *reqOptions) ContentType {
+
+	if o.contentType != nil {
+		return *o.contentType
+	}
+
+	return rb.ContentType
+}
+
+// basicAuth returns the Basic Auth of the request.
+func (rb *RequestBuilder) basicAuth(o *reqOptions) *BasicAuth {
+
+	if o.basicAuth != nil {
+		return o.basicAuth
+	}
+
+	return rb.BasicAuth
+}
+
+// withQuery adds the query parameters of the request to the URL.
+func (o *reqOptions) withQuery(reqURL string) (string, error) {
+
+	if len(o.query) == 0 {
+		return reqURL, nil
+	}
+
+	u, err := url.Parse(reqURL)
+	if err != nil {
+		return reqURL, err
+	}
+
+	query := u.Query()
+	for k, v := range o.query {
+		query[k] = append(query[k], v...)
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
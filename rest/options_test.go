@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithQuery(t *testing.T) {
+
+	var echo Echo
+
+	resp := rb.Get("/echo?a=1", WithQuery("b", "2"), WithQuery("a", "3"), WithQuery("c", "x y"))
+
+	if err := resp.FillUp(&echo); err != nil {
+		t.Fatal(err)
+	}
+
+	if echo.URL != "/echo?a=1&a=3&b=2&c=x+y" {
+		t.Fatal("Wrong query: " + echo.URL)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+
+	if resp := rb.Get("/slow/user", WithTimeout(5*time.Millisecond)); resp.Err == nil {
+		t.Fatal("Request should have timed out")
+	}
+
+	builder := RequestBuilder{
+		BaseURL: server.URL,
+		Timeout: 5 * time.Millisecond,
+	}
+
+	if resp := builder.Get("/slow/user", WithTimeout(time.Second)); resp.Err != nil {
+		t.Fatal(resp.Err)
+	}
+
+	if resp := builder.Get("/slow/user"); resp.Err == nil {
+		t.Fatal("Request should have timed out")
+	}
+}
+
+func TestWithoutCache(t *testing.T) {
+
+	rb.Get("/echo?cache-control=max-age=10&test=without-cache")
+
+	if rb.Get("/echo?cache-control=max-age=10&test=without-cache", WithoutCache()).CacheHit() {
+		t.Fatal("Response should not come from the cache")
+	}
+
+	if !rb.Get("/echo?cache-control=max-age=10&test=without-cache").CacheHit() {
+		t.Fatal("Response should come from the cache")
+	}
+}
+
+func TestWithContentType(t *testing.T) {
+
+	var echo Echo
+
+	resp := rb.Post("/echo", &User{Name: "Matilda"}, WithContentType(XML))
+
+	if err := resp.FillUp(&echo); err != nil {
+		t.Fatal(err)
+	}
+
+	switch {
+	case echo.Header.Get("Content-Type") != "application/xml":
+		t.Fatal("Wrong Content-Type: " + echo.Header.Get("Content-Type"))
+	case echo.Header.Get("Accept") != "application/xml":
+		t.Fatal("Wrong Accept: " + echo.Header.Get("Accept"))
+	case !strings.HasPrefix(echo.Body, "<User>"):
+		t.Fatal("Body was not marshalled as XML: " + echo.Body)
+	}
+}
+
+func TestWithBasicAuth(t *testing.T) {
+
+	var echo Echo
+
+	builder := RequestBuilder{
+		BaseURL:   server.URL,
+		BasicAuth: &BasicAuth{"user", "pass"},
+	}
+
+	if err := builder.Get("/echo", WithBasicAuth("other", "secret")).FillUp(&echo); err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("other", "secret")
+
+	if echo.Header.Get("Authorization") != req.Header.Get("Authorization") {
+		t.Fatal("Wrong Authorization: " + echo.Header.Get("Authorization"))
+	}
+}
+
+func TestForkJoinWithOptions(t *testing.T) {
+
+	var f *FutureResponse
+	var echo Echo
+
+	rb.ForkJoin(func(c *Concurrent) {
+		f = c.Get("/echo", WithHeader("X-Test", "fork"), WithQuery("q", "join"))
+	})
+
+	if err := f.Response().FillUp(&echo); err != nil {
+		t.Fatal(err)
+	}
+
+	if echo.Header.Get("X-Test") != "fork" || echo.URL != "/echo?q=join" {
+		t.Fatal("Options were not applied to the concurrent request")
+	}
+}
+
+func TestAsyncWithOptions(t *testing.T) {
+
+	done := make(chan *Response)
+
+	rb.AsyncPost("/echo", "body", func(r *Response) {
+		done <- r
+	}, WithHeader("X-Test", "async"))
+
+	var echo Echo
+
+	if err := (<-done).FillUp(&echo); err != nil {
+		t.Fatal(err)
+	}
+
+	if echo.Header.Get("X-Test") != "async" {
+		t.Fatal("Options were not applied to the async request")
+	}
+}
@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+)
+
+// Problem is a problem details error body (RFC 9457, formerly RFC 7807),
+// served as application/problem+json or application/problem+xml.
+//
+//	if p := resp.Problem(); p != nil {
+//		log.Print(p.Title, p.Detail, p.Extensions["balance"])
+//	}
+type Problem struct {
+	XMLName xml.Name `xml:"problem"`
+
+	// URI identifying the problem type. "about:blank" if missing.
+	Type string `xml:"type"`
+
+	// Short summary of the problem type.
+	Title string `xml:"title"`
+
+	// Status code generated by the origin server.
+	Status int `xml:"status"`
+
+	// Explanation of this occurrence of the problem.
+	Detail string `xml:"detail"`
+
+	// URI identifying this occurrence of the problem.
+	Instance string `xml:"instance"`
+
+	// Any other members. Only decoded from JSON.
+	Extensions map[string]interface{} `xml:"-"`
+}
+
+func (p *Problem) Error() string {
+
+	msg := p.Title
+	if msg == "" {
+		msg = p.Type
+	}
+
+	if p.Detail != "" {
+		msg += ": " + p.Detail
+	}
+
+	return msg
+}
+
+// UnmarshalJSON decodes the problem, and its extension members. Members
+// with a wrong type are ignored, as RFC 9457 requires.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	*p = Problem{}
+
+	fields := map[string]interface{}{
+		"type":     &p.Type,
+		"title":    &p.Title,
+		"status":   &p.Status,
+		"detail":   &p.Detail,
+		"instance": &p.Instance,
+	}
+
+	for name, raw := range members {
+
+		if field, ok := fields[name]; ok {
+			json.Unmarshal(raw, field)
+			continue
+		}
+
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]interface{})
+		}
+
+		p.Extensions[name] = v
+	}
+
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the problem, with its extension members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+
+	add := func(name string, v interface{}, empty bool) {
+		if !empty {
+			members[name] = v
+		}
+	}
+
+	add("type", p.Type, p.Type == "")
+	add("title", p.Title, p.Title == "")
+	add("status", p.Status, p.Status == 0)
+	add("detail", p.Detail, p.Detail == "")
+	add("instance", p.Instance, p.Instance == "")
+
+	return json.Marshal(members)
+}
+
+// Problem returns the problem details of the response, if its body is
+// application/problem+json or application/problem+xml. Otherwise nil.
+func (r *Response) Problem() *Problem {
+
+	if r.Response == nil {
+		return nil
+	}
+
+	ctype := strings.ToLower(r.Header.Get("Content-Type"))
+	if !strings.HasPrefix(ctype, "application/problem+") {
+		return nil
+	}
+
+	p := new(Problem)
+	if r.FillUp(p) != nil {
+		return nil
+	}
+
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+
+	return p
+}
@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestProblem(t *testing.T) {
+
+	ms := NewMockServer(t)
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/problem/json",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusForbidden,
+		RespHeaders:  http.Header{"Content-Type": {"application/problem+json"}},
+		RespBody: `{"type": "https://example.com/probs/out-of-credit", "title": "You do not have enough credit.",
+			"status": 403, "detail": "Your current balance is 30, but that costs 50.", "balance": 30, "instance": 7}`,
+	}, &Mock{
+		URL:          "http://mytest.com/problem/xml",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusNotFound,
+		RespHeaders:  http.Header{"Content-Type": {"application/problem+xml"}},
+		RespBody: `<?xml version="1.0" encoding="UTF-8"?>
+<problem xmlns="urn:ietf:rfc:7807"><title>Not found</title><status>404</status></problem>`,
+	})
+
+	rb := &RequestBuilder{MockServer: ms}
+
+	resp := rb.Get("http://mytest.com/problem/json")
+
+	expected := &Problem{
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "You do not have enough credit.",
+		Status:     403,
+		Detail:     "Your current balance is 30, but that costs 50.",
+		Extensions: map[string]interface{}{"balance": float64(30)},
+	}
+
+	if p := resp.Problem(); !reflect.DeepEqual(p, expected) {
+		t.Fatalf("Wrong problem %+v", p)
+	}
+
+	var httpErr *HTTPError
+	if err := resp.Error(); !errors.As(err, &httpErr) || !reflect.DeepEqual(httpErr.Payload, expected) {
+		t.Fatal("The payload should be the problem", err)
+	}
+
+	data, _ := json.Marshal(expected)
+	if string(data) != `{"balance":30,"detail":"Your current balance is 30, but that costs 50.","status":403,`+
+		`"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit"}` {
+		t.Fatal("Wrong JSON", string(data))
+	}
+
+	p := rb.Get("http://mytest.com/problem/xml").Problem()
+	if p == nil || p.Title != "Not found" || p.Status != http.StatusNotFound || p.Type != "about:blank" {
+		t.Fatalf("Wrong problem %+v", p)
+	}
+
+	if p := rb.Get("http://mytest.com/problem/unmatched").Problem(); p != nil {
+		t.Fatal("Only problem details are problems", p)
+	}
+}
+
+func TestErrorPayload(t *testing.T) {
+
+	type apiError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	ms := NewMockServer(t)
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/problem/custom",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusBadRequest,
+		RespHeaders:  http.Header{"Content-Type": {"application/vnd.api+json"}},
+		RespBody:     `{"code": "invalid", "message": "Invalid name"}`,
+	})
+
+	rb := &RequestBuilder{
+		MockServer:   ms,
+		ErrorPayload: func() interface{} { return new(apiError) },
+	}
+
+	var httpErr *HTTPError
+	if err := rb.Get("http://mytest.com/problem/custom").Error(); !errors.As(err, &httpErr) {
+		t.Fatal("Wrong error", err)
+	}
+
+	if payload, ok := httpErr.Payload.(*apiError); !ok || payload.Code != "invalid" {
+		t.Fatal("Wrong payload", httpErr.Payload)
+	}
+}
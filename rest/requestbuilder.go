@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"crypto/tls"
 	"net/http"
 	"sync"
 	"time"
@@ -55,6 +56,11 @@ type RequestBuilder struct {
 	// Disable internal caching of Responses
 	DisableCache bool
 
+	// Cache directives for every request. They are sent as the Cache-Control
+	// header, overriding the one in Headers, if any.
+	// If nil, the Cache-Control header in Headers is used.
+	CacheControl *CacheControl
+
 	// Disable timeout and deafult timeout = no timeout
 	DisableTimeout bool
 
@@ -68,6 +74,27 @@ type RequestBuilder struct {
 	// Set an specific User Agent for this RequestBuilder
 	UserAgent string
 
+	// Record the requests of this RequestBuilder, or replay them.
+	Cassette *Cassette
+
+	// Send every request of this RequestBuilder to this mockup server,
+	// whether the mockup environment is activated or not.
+	MockServer *MockServer
+
+	// Status codes of successful responses. Responses out of this range have
+	// a *HTTPError, returned by Response.Error. If empty, DefaultSuccessRange
+	// is used.
+	SuccessRange StatusRange
+
+	// Set the Err of responses out of the SuccessRange to their *HTTPError.
+	StatusErrors bool
+
+	// Returns a new value, like a pointer to a struct, where the bodies of
+	// responses out of the SuccessRange are decoded with FillUp, as the
+	// Payload of their *HTTPError. If nil, problem details are decoded as
+	// *Problem, and other JSON bodies as maps, slices and values.
+	ErrorPayload func() interface{}
+
 	client        *http.Client
 	clientMtxOnce sync.Once
 }
@@ -76,6 +103,10 @@ type RequestBuilder struct {
 type CustomPool struct {
 	MaxIdleConnsPerHost int
 	Proxy               string
+
+	// TLS configuration of the transport, like client certificates or
+	// trusted CAs. If nil, the default configuration is used.
+	TLSClientConfig *tls.Config
 }
 
 // BasicAuth gives the possibility to set UserName and Password for a given
@@ -90,8 +121,8 @@ type BasicAuth struct {
 // In Restful, GET is used for "reading" or retrieving a resource.
 // Client should expect a response status code of 200(OK) if resource exists,
 // 404(Not Found) if it doesn't, or 400(Bad Request).
-func (rb *RequestBuilder) Get(url string) *Response {
-	return rb.doRequest(http.MethodGet, url, nil)
+func (rb *RequestBuilder) Get(url string, opts ...RequestOption) *Response {
+	return rb.doRequest(http.MethodGet, url, nil, opts)
 }
 
 // Post issues a POST HTTP verb to the specified URL.
@@ -101,8 +132,8 @@ func (rb *RequestBuilder) Get(url string) *Response {
 // 404(Not Found), or 409(Conflict) if resource already exist.
 //
 // Body could be any of the form: string, []byte, struct & map.
-func (rb *RequestBuilder) Post(url string, body interface{}) *Response {
-	return rb.doRequest(http.MethodPost, url, body)
+func (rb *RequestBuilder) Post(url string, body interface{}, opts ...RequestOption) *Response {
+	return rb.doRequest(http.MethodPost, url, body, opts)
 }
 
 // Put issues a PUT HTTP verb to the specified URL.
@@ -112,8 +143,8 @@ func (rb *RequestBuilder) Post(url string, body interface{}) *Response {
 // or 400(Bad Request). 200(OK) could be also 204(No Content)
 //
 // Body could be any of the form: string, []byte, struct & map.
-func (rb *RequestBuilder) Put(url string, body interface{}) *Response {
-	return rb.doRequest(http.MethodPut, url, body)
+func (rb *RequestBuilder) Put(url string, body interface{}, opts ...RequestOption) *Response {
+	return rb.doRequest(http.MethodPut, url, body, opts)
 }
 
 // Patch issues a PATCH HTTP verb to the specified URL.
@@ -123,8 +154,8 @@ func (rb *RequestBuilder) Put(url string, body interface{}) *Response {
 // or 400(Bad Request). 200(OK) could be also 204(No Content)
 //
 // Body could be any of the form: string, []byte, struct & map.
-func (rb *RequestBuilder) Patch(url string, body interface{}) *Response {
-	return rb.doRequest(http.MethodPatch, url, nil)
+func (rb *RequestBuilder) Patch(url string, body interface{}, opts ...RequestOption) *Response {
+	return rb.doRequest(http.MethodPatch, url, body, opts)
 }
 
 // Delete issues a DELETE HTTP verb to the specified URL
@@ -132,8 +163,8 @@ func (rb *RequestBuilder) Patch(url string, body interface{}) *Response {
 // In Restful, DELETE is used to "delete" a resource.
 // Client should expect a response status code of of 200(OK), 404(Not Found),
 // or 400(Bad Request).
-func (rb *RequestBuilder) Delete(url string) *Response {
-	return rb.doRequest(http.MethodDelete, url, nil)
+func (rb *RequestBuilder) Delete(url string, opts ...RequestOption) *Response {
+	return rb.doRequest(http.MethodDelete, url, nil, opts)
 }
 
 // Head issues a HEAD HTTP verb to the specified URL
@@ -141,8 +172,8 @@ func (rb *RequestBuilder) Delete(url string) *Response {
 // In Restful, HEAD is used to "read" a resource headers only.
 // Client should expect a response status code of 200(OK) if resource exists,
 // 404(Not Found) if it doesn't, or 400(Bad Request).
-func (rb *RequestBuilder) Head(url string) *Response {
-	return rb.doRequest(http.MethodHead, url, nil)
+func (rb *RequestBuilder) Head(url string, opts ...RequestOption) *Response {
+	return rb.doRequest(http.MethodHead, url, nil, opts)
 }
 
 // Options issues a OPTIONS HTTP verb to the specified URL
@@ -151,64 +182,64 @@ func (rb *RequestBuilder) Head(url string) *Response {
 // and supported HTTP verbs.
 // Client should expect a response status code of 200(OK) if resource exists,
 // 404(Not Found) if it doesn't, or 400(Bad Request).
-func (rb *RequestBuilder) Options(url string) *Response {
-	return rb.doRequest(http.MethodOptions, url, nil)
+func (rb *RequestBuilder) Options(url string, opts ...RequestOption) *Response {
+	return rb.doRequest(http.MethodOptions, url, nil, opts)
 }
 
 // AsyncGet is the *asynchronous* option for GET.
 // The go routine calling AsyncGet(), will not be blocked.
 //
 // Whenever the Response is ready, the *f* function will be called back.
-func (rb *RequestBuilder) AsyncGet(url string, f func(*Response)) {
-	go doAsyncRequest(rb.Get(url), f)
+func (rb *RequestBuilder) AsyncGet(url string, f func(*Response), opts ...RequestOption) {
+	go doAsyncRequest(rb.Get(url, opts...), f)
 }
 
 // AsyncPost is the *asynchronous* option for POST.
 // The go routine calling AsyncPost(), will not be blocked.
 //
 // Whenever the Response is ready, the *f* function will be called back.
-func (rb *RequestBuilder) AsyncPost(url string, body interface{}, f func(*Response)) {
-	go doAsyncRequest(rb.Post(url, body), f)
+func (rb *RequestBuilder) AsyncPost(url string, body interface{}, f func(*Response), opts ...RequestOption) {
+	go doAsyncRequest(rb.Post(url, body, opts...), f)
 }
 
 // AsyncPut is the *asynchronous* option for PUT.
 // The go routine calling AsyncPut(), will not be blocked.
 //
 // Whenever the Response is ready, the *f* function will be called back.
-func (rb *RequestBuilder) AsyncPut(url string, body interface{}, f func(*Response)) {
-	go doAsyncRequest(rb.Put(url, body), f)
+func (rb *RequestBuilder) AsyncPut(url string, body interface{}, f func(*Response), opts ...RequestOption) {
+	go doAsyncRequest(rb.Put(url, body, opts...), f)
 }
 
 // AsyncPatch is the *asynchronous* option for PATCH.
 // The go routine calling AsyncPatch(), will not be blocked.
 //
 // Whenever the Response is ready, the *f* function will be called back.
-func (rb *RequestBuilder) AsyncPatch(url string, body interface{}, f func(*Response)) {
-	go doAsyncRequest(rb.Patch(url, body), f)
+func (rb *RequestBuilder) AsyncPatch(url string, body interface{}, f func(*Response), opts ...RequestOption) {
+	go doAsyncRequest(rb.Patch(url, body, opts...), f)
 }
 
 // AsyncDelete is the *asynchronous* option for DELETE.
 // The go routine calling AsyncDelete(), will not be blocked.
 //
 // Whenever the Response is ready, the *f* function will be called back.
-func (rb *RequestBuilder) AsyncDelete(url string, f func(*Response)) {
-	go doAsyncRequest(rb.Delete(url), f)
+func (rb *RequestBuilder) AsyncDelete(url string, f func(*Response), opts ...RequestOption) {
+	go doAsyncRequest(rb.Delete(url, opts...), f)
 }
 
 // AsyncHead is the *asynchronous* option for HEAD.
 // The go routine calling AsyncHead(), will not be blocked.
 //
 // Whenever the Response is ready, the *f* function will be called back.
-func (rb *RequestBuilder) AsyncHead(url string, f func(*Response)) {
-	go doAsyncRequest(rb.Head(url), f)
+func (rb *RequestBuilder) AsyncHead(url string, f func(*Response), opts ...RequestOption) {
+	go doAsyncRequest(rb.Head(url, opts...), f)
 }
 
 // AsyncOptions is the *asynchronous* option for OPTIONS.
 // The go routine calling AsyncOptions(), will not be blocked.
 //
 // Whenever the Response is ready, the *f* function will be called back.
-func (rb *RequestBuilder) AsyncOptions(url string, f func(*Response)) {
-	go doAsyncRequest(rb.Options(url), f)
+func (rb *RequestBuilder) AsyncOptions(url string, f func(*Response), opts ...RequestOption) {
+	go doAsyncRequest(rb.Options(url, opts...), f)
 }
 
 func doAsyncRequest(r *Response, f func(*Response)) {
@@ -33,6 +33,17 @@ const (
 // Type: rest.ByteSize
 var MaxCacheSize = 1 * GB
 
+// MaxCacheEntrySize is the Maximum Byte Size a single Response may have to be
+// hold by the ResourceCache. Bigger Responses are never cached.
+// Regardless of this value, a Response bigger than 1/maxCacheEntryRatio of
+// MaxCacheSize is never cached either, so a single entry can't flush the cache.
+// Default is 0, meaning only the MaxCacheSize ratio applies.
+// Type: rest.ByteSize
+var MaxCacheEntrySize ByteSize
+
+// A Response can take at most 1/maxCacheEntryRatio of MaxCacheSize.
+const maxCacheEntryRatio = 10
+
 // Current Cache Size.
 var cacheSize int64
 
@@ -86,7 +97,7 @@ func (rCache *resourceTtlLruMap) lruOperations() {
 		case move:
 			rCache.lruList.MoveToFront(msg.resp.listElement)
 		case push:
-			msg.resp.listElement = rCache.lruList.PushFront(msg.resp.Request.URL.String())
+			msg.resp.listElement = rCache.lruList.PushFront(msg.resp.cacheKey)
 		case del:
 			rCache.lruList.Remove(msg.resp.listElement)
 		case last:
@@ -105,7 +116,7 @@ func (rCache *resourceTtlLruMap) get(key string) *Response {
 	rCache.rwMutex.RUnlock()
 
 	//If expired, remove it
-	if resp != nil && resp.ttl != nil && resp.ttl.Sub(time.Now()) <= 0 {
+	if resp != nil && resp.evictable(time.Now()) {
 
 		//Full lock
 		rCache.rwMutex.Lock()
@@ -115,7 +126,7 @@ func (rCache *resourceTtlLruMap) get(key string) *Response {
 		resp = rCache.cache[key]
 
 		//Check again with the lock
-		if resp != nil && resp.ttl != nil && resp.ttl.Sub(time.Now()) <= 0 {
+		if resp != nil && resp.evictable(time.Now()) {
 			rCache.remove(key, resp)
 			return nil //return. Do not send the move message
 		}
@@ -136,51 +147,61 @@ func (rCache *resourceTtlLruMap) get(key string) *Response {
 	return resp
 }
 
-// Set if key not exist
-func (rCache *resourceTtlLruMap) setNX(key string, value *Response) {
+// Set, replacing the Response already cached for the key, if any.
+func (rCache *resourceTtlLruMap) set(key string, value *Response) {
 
 	//Full Lock
 	rCache.rwMutex.Lock()
 	defer rCache.rwMutex.Unlock()
 
-	v := rCache.cache[key]
+	if v := rCache.cache[key]; v != nil {
+		if v == value {
+			return
+		}
 
-	if v == nil {
+		rCache.remove(key, v)
+	}
 
-		rCache.cache[key] = value
+	value.cacheKey = key
+	value.cacheSize = value.entrySize()
 
-		//PushFront in LruList
-		rCache.lruChan <- &lruMsg{
-			operation: push,
-			resp:      value,
-		}
+	// Too big to be cached
+	if value.cacheSize > maxEntrySize() {
+		return
+	}
 
-		//Set ttl if necessary
-		if value.ttl != nil {
-			value.skipListElement = rCache.skipList.insert(key, *value.ttl)
-			rCache.ttlChan <- true
-		}
+	rCache.cache[key] = value
 
-		// Add Response Size to Cache
-		// Not necessary to use atomic
-		cacheSize += value.size()
+	//PushFront in LruList
+	rCache.lruChan <- &lruMsg{
+		operation: push,
+		resp:      value,
+	}
 
-		for i := 0; ByteSize(cacheSize) >= MaxCacheSize && i < 10; i++ {
+	//Set ttl if necessary
+	if value.ttl != nil {
+		value.skipListElement = rCache.skipList.insert(key, value.ttl.Add(StaleCacheRetention))
+		rCache.ttlChan <- true
+	}
 
-			rCache.lruChan <- &lruMsg{
-				last,
-				nil,
-			}
+	// Add Response Size to Cache
+	// Not necessary to use atomic
+	cacheSize += value.cacheSize
 
-			k := <-rCache.popChan
-			r := rCache.cache[k]
+	for i := 0; ByteSize(cacheSize) >= MaxCacheSize && i < 10; i++ {
 
-			rCache.remove(k, r)
+		rCache.lruChan <- &lruMsg{
+			last,
+			nil,
+		}
 
+		k := <-rCache.popChan
+
+		if r := rCache.cache[k]; r != nil {
+			rCache.remove(k, r)
 		}
 
 	}
-
 }
 
 //
@@ -195,7 +216,25 @@ func (rCache *resourceTtlLruMap) remove(key string, resp *Response) {
 
 	// Delete bytes cache
 	// Not need for atomic
-	cacheSize -= resp.size()
+	cacheSize -= resp.cacheSize
+}
+
+// evictable tells if the Response has been expired for longer than
+// StaleCacheRetention, and it should be flushed from the cache.
+func (r *Response) evictable(now time.Time) bool {
+	return r.skipListElement != nil && r.skipListElement.ttl.Sub(now) <= 0
+}
+
+// maxEntrySize is the maximum size a Response may have to be cached.
+func maxEntrySize() int64 {
+
+	max := int64(MaxCacheSize) / maxCacheEntryRatio
+
+	if MaxCacheEntrySize > 0 && int64(MaxCacheEntrySize) < max {
+		max = int64(MaxCacheEntrySize)
+	}
+
+	return max
 }
 
 func (rCache *resourceTtlLruMap) ttl() {
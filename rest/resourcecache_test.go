@@ -3,6 +3,7 @@ package rest
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -151,3 +152,65 @@ func TestCacheSlowForkJoinGet(t *testing.T) {
 	}
 
 }
+
+func TestCacheSizeCountsHeaders(t *testing.T) {
+
+	resp := rb.Get("/user")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+
+	size := resp.size()
+
+	resp.Header.Set("X-Big-Header", strings.Repeat("a", 1024))
+
+	if resp.size()-size < 1024 {
+		t.Fatal("Response headers are not accounted in the cache size")
+	}
+
+	resp.Request.Header.Set("X-Big-Header", strings.Repeat("a", 1024))
+
+	if resp.size()-size < 2048 {
+		t.Fatal("Request headers are not accounted in the cache size")
+	}
+
+	if resp.entrySize() <= resp.size() {
+		t.Fatal("Cache index overhead is not accounted in the entry size")
+	}
+}
+
+func TestCacheRefusesBigEntries(t *testing.T) {
+
+	mces := MaxCacheEntrySize
+	defer func() { MaxCacheEntrySize = mces }()
+
+	MaxCacheEntrySize = 10
+
+	rb.Get("/cache/user?refuse=big")
+
+	if resp := rb.Get("/cache/user?refuse=big"); resp.CacheHit() {
+		t.Fatal("Response bigger than MaxCacheEntrySize was cached")
+	}
+
+	MaxCacheEntrySize = mces
+
+	rb.Get("/cache/user?refuse=small")
+
+	if resp := rb.Get("/cache/user?refuse=small"); !resp.CacheHit() {
+		t.Fatal("Response should have been cached")
+	}
+}
+
+func TestCacheRefusesEntriesBiggerThanBudgetRatio(t *testing.T) {
+
+	mcs := MaxCacheSize
+	defer func() { MaxCacheSize = mcs }()
+
+	MaxCacheSize = 4 * KB
+
+	rb.Get("/cache/user?refuse=ratio")
+
+	if resp := rb.Get("/cache/user?refuse=ratio"); resp.CacheHit() {
+		t.Fatal("Response bigger than a tenth of MaxCacheSize was cached")
+	}
+}
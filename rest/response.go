@@ -23,40 +23,162 @@ type Response struct {
 	ttl             *time.Time
 	lastModified    *time.Time
 	etag            string
+	cacheKey        string
+	cacheSize       int64
 	revalidate      bool
 	cacheHit        atomic.Value
+
+	// Success range and ErrorPayload of the RequestBuilder
+	success      StatusRange
+	errorPayload func() interface{}
 }
 
+// Rough per-object overheads, in bytes, used when estimating how much
+// memory a cached Response takes. They don't need to be exact, but they
+// must not be zero, otherwise MaxCacheSize would be badly underestimated.
+const (
+	// A map entry: tophash, key slot and value slot, plus bucket slack.
+	mapEntryOverhead = 48
+
+	// A slice or string allocation is rounded up by the allocator.
+	allocOverhead = 8
+)
+
+var (
+	stringHeaderSize = int64(unsafe.Sizeof(""))
+	timeSize         = int64(unsafe.Sizeof(time.Time{}))
+)
+
+// size estimates the memory held by the Response: the struct itself, the
+// body, and everything reachable from the embedded *http.Response, including
+// the headers and the *http.Request that originated it.
 func (r *Response) size() int64 {
 
 	size := int64(unsafe.Sizeof(*r))
 
-	size += int64(len(r.byteBody))
-	size += int64(unsafe.Sizeof(*r.listElement))
-	size += int64(unsafe.Sizeof(*r.skipListElement))
-	size += int64(unsafe.Sizeof(*r.ttl))
-	size += int64(unsafe.Sizeof(*r.lastModified))
-	size += int64(len(r.etag))
-
-	size += int64(len(r.Response.Proto))
-	size += int64(len(r.Response.Status))
-	/*r.Response.Header
-	r.Response.TLS
-	r.Response.Trailer
-	r.Response.TransferEncoding
-
-
-	r.Response.Request.Header
-	r.Response.Request.Host
-	r.Response.Request.Method
-	r.Response.Request.Proto
-	r.Response.Request.RemoteAddr
-	r.Response.Request.RequestURI
-	*/
+	size += int64(cap(r.byteBody)) + allocOverhead
+	size += stringSize(r.etag)
+	size += stringSize(r.cacheKey)
+
+	if r.ttl != nil {
+		size += timeSize
+	}
+
+	if r.lastModified != nil {
+		size += timeSize
+	}
+
+	if resp := r.Response; resp != nil {
+
+		size += int64(unsafe.Sizeof(*resp))
+		size += stringSize(resp.Status)
+		size += stringSize(resp.Proto)
+		size += headerSize(resp.Header)
+		size += headerSize(resp.Trailer)
+		size += stringsSize(resp.TransferEncoding)
+
+		if resp.TLS != nil {
+			size += int64(unsafe.Sizeof(*resp.TLS))
+			for _, cert := range resp.TLS.PeerCertificates {
+				size += int64(unsafe.Sizeof(*cert)) + int64(len(cert.Raw))
+			}
+		}
+
+		if req := resp.Request; req != nil {
+			size += int64(unsafe.Sizeof(*req))
+			size += stringSize(req.Method)
+			size += stringSize(req.Proto)
+			size += stringSize(req.Host)
+			size += stringSize(req.RemoteAddr)
+			size += stringSize(req.RequestURI)
+			size += headerSize(req.Header)
+			size += headerSize(req.Trailer)
+
+			if req.URL != nil {
+				size += int64(unsafe.Sizeof(*req.URL))
+				size += stringSize(req.URL.String())
+			}
+		}
+	}
+
+	return size
+}
+
+// entrySize is the size of the Response plus the overhead of indexing it in
+// the ResourceCache: the map entry, the LRU list element and the TTL skiplist
+// node. All of them share the same key string.
+func (r *Response) entrySize() int64 {
+
+	size := r.size()
+
+	size += mapEntryOverhead
+	size += int64(unsafe.Sizeof(list.Element{})) + stringHeaderSize
+
+	if r.ttl != nil {
+		// On average a skiplist node has two levels
+		size += int64(unsafe.Sizeof(skipListNode{})) + 2*int64(unsafe.Sizeof(r.skipListElement))
+	}
+
+	return size
+}
+
+func headerSize(h http.Header) int64 {
+
+	var size int64
+
+	for k, v := range h {
+		size += mapEntryOverhead + stringSize(k) + stringsSize(v)
+	}
 
 	return size
 }
 
+// stringsSize is the size of the backing array of a []string and the bytes
+// of every string in it. The slice header itself is accounted by its holder.
+func stringsSize(s []string) int64 {
+
+	if len(s) == 0 {
+		return 0
+	}
+
+	size := int64(len(s))*stringHeaderSize + allocOverhead
+
+	for _, v := range s {
+		size += stringSize(v)
+	}
+
+	return size
+}
+
+// stringSize is the size of the bytes of a string. The string header itself
+// is accounted by its holder.
+func stringSize(s string) int64 {
+
+	if s == "" {
+		return 0
+	}
+
+	return int64(len(s)) + allocOverhead
+}
+
+// hit returns a copy of the cached Response, for a request served from the
+// cache, so each request has its own Err and success range.
+func (r *Response) hit() *Response {
+
+	c := &Response{
+		Response:     r.Response,
+		byteBody:     r.byteBody,
+		ttl:          r.ttl,
+		lastModified: r.lastModified,
+		etag:         r.etag,
+		revalidate:   r.revalidate,
+	}
+
+	c.cacheHit.Store(true)
+
+	return c
+}
+
 // String return the Respnse Body as a String.
 func (r *Response) String() string {
 	return string(r.Bytes())
@@ -69,19 +191,17 @@ func (r *Response) Bytes() []byte {
 
 // FillUp set the *fill* parameter with the corresponding JSON or XML response.
 // fill could be `struct` or `map[string]interface{}`
+// Structured syntax suffixes are recognized too, like application/problem+json
 func (r *Response) FillUp(fill interface{}) error {
 
-	ctypeJSON := "application/json"
-	ctypeXML := "application/xml"
-
-	ctype := strings.ToLower(r.Header.Get("Content-Type"))
+	ctype := r.Header.Get("Content-Type")
 
 	for i := 0; i < 2; i++ {
 
 		switch {
-		case strings.Contains(ctype, ctypeJSON):
+		case isJSONMediaType(ctype):
 			return json.Unmarshal(r.byteBody, fill)
-		case strings.Contains(ctype, ctypeXML):
+		case isXMLMediaType(ctype):
 			return xml.Unmarshal(r.byteBody, fill)
 		case i == 0:
 			ctype = http.DetectContentType(r.byteBody)
@@ -93,6 +213,20 @@ func (r *Response) FillUp(fill interface{}) error {
 
 }
 
+// isJSONMediaType tells if a media type is JSON, like application/json or
+// application/problem+json
+func isJSONMediaType(t string) bool {
+	t = strings.ToLower(t)
+	return strings.HasPrefix(t, "application/json") || strings.Contains(t, "+json")
+}
+
+// isXMLMediaType tells if a media type is XML, like application/xml or
+// application/problem+xml
+func isXMLMediaType(t string) bool {
+	t = strings.ToLower(t)
+	return strings.HasPrefix(t, "application/xml") || strings.HasPrefix(t, "text/xml") || strings.Contains(t, "+xml")
+}
+
 // CacheHit shows if a response was get from the cache.
 func (r *Response) CacheHit() bool {
 	if hit, ok := r.cacheHit.Load().(bool); hit && ok {
@@ -77,6 +77,20 @@ func TestPatch(t *testing.T) {
 	}
 }
 
+func TestPatchBody(t *testing.T) {
+
+	var echo Echo
+
+	resp := Patch(server.URL+"/echo", &User{Name: "Pichucha"})
+	if err := resp.FillUp(&echo); err != nil {
+		t.Fatal(err)
+	}
+
+	if echo.Method != http.MethodPatch || echo.Body != `{"id":0,"name":"Pichucha"}` {
+		t.Fatal("PATCH body was not sent: " + echo.Body)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	resp := Delete(server.URL + "/user/4")
 
@@ -188,6 +202,74 @@ func TestHeaders(t *testing.T) {
 
 }
 
+func TestHeadersNotModified(t *testing.T) {
+
+	h := make(http.Header)
+	h.Add("X-Test", "test")
+
+	builder := RequestBuilder{
+		BaseURL:   server.URL,
+		Headers:   h,
+		BasicAuth: &BasicAuth{"user", "pass"},
+	}
+
+	builder.Get("/header")
+
+	if len(h) != 1 || h.Get("X-Test") != "test" {
+		t.Fatal("RequestBuilder Headers were modified by the request")
+	}
+}
+
+func TestWithHeader(t *testing.T) {
+
+	var echo Echo
+
+	h := make(http.Header)
+	h.Add("X-Test", "test")
+	h.Add("X-Builder", "builder")
+
+	builder := RequestBuilder{
+		BaseURL: server.URL,
+		Headers: h,
+	}
+
+	resp := builder.Get("/echo",
+		WithHeader("X-Test", "request"),
+		WithHeader("X-Other", "1"),
+		WithHeader("X-Other", "2"),
+		WithHeader("User-Agent", "my-agent"))
+
+	if err := resp.FillUp(&echo); err != nil {
+		t.Fatal(err)
+	}
+
+	switch {
+	case echo.Header.Get("X-Test") != "request":
+		t.Fatal("Request header should override the builder one")
+	case echo.Header.Get("X-Builder") != "builder":
+		t.Fatal("Builder header should be sent")
+	case len(echo.Header["X-Other"]) != 2:
+		t.Fatal("Every value of a request header should be sent")
+	case echo.Header.Get("User-Agent") != "my-agent":
+		t.Fatal("Request header should override the default ones")
+	}
+
+	if len(h) != 2 || h.Get("X-Test") != "test" {
+		t.Fatal("RequestBuilder Headers were modified by the request")
+	}
+}
+
+func TestWithHeaderNotModified(t *testing.T) {
+
+	// The caller revalidates its own copy, so there is nothing in the cache
+	builder := RequestBuilder{DisableCache: true}
+	resp := builder.Get(server.URL+"/cache/etag/user", WithHeader("If-None-Match", "1234"))
+
+	if resp == nil || resp.Err != nil || resp.StatusCode != http.StatusNotModified {
+		t.Fatal("304 should be returned as is")
+	}
+}
+
 func TestWrongURL(t *testing.T) {
 	r := Get("foo")
 	if r.Err == nil {
@@ -0,0 +1,205 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrBodyTooLarge is the error of a response whose body is longer than the
+// maximum set with WithMaxBodySize.
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// GetStream issues a GET HTTP verb to the specified URL, without reading the
+// response body: resp.Body is the live body of the connection.
+//
+// The caller is responsible for closing resp.Body, if resp.Response is not
+// nil, even when it is not read.
+//
+//	resp := rb.GetStream("/exports/1", rest.WithMaxBodySize(1<<30))
+//	if resp.Err != nil {
+//		return resp.Err
+//	}
+//	defer resp.Body.Close()
+//
+//	_, err := io.Copy(file, resp.Body)
+//
+// Streamed responses are never cached, nor recorded by a Cassette, and Bytes,
+// String and FillUp are empty. Bodies of status codes out of the success
+// range are read anyway, so the *HTTPError of resp.Error() has them. Without
+// WithMaxBodySize, they are cut at 1 MiB.
+//
+// Auth, headers and mockups apply as in Get. The time out of the
+// RequestBuilder, or WithTimeout, only applies until the response headers
+// arrive, so the body could take as long as needed. To bound it, close the
+// body.
+func (rb *RequestBuilder) GetStream(url string, opts ...RequestOption) *Response {
+	return rb.doRequest(http.MethodGet, url, nil, append([]RequestOption{streamOption}, opts...))
+}
+
+// GetStream issues a GET HTTP verb to the specified URL, without reading the
+// response body. See RequestBuilder.GetStream
+//
+// GetStream uses the DefaultBuilder.
+func GetStream(url string, opts ...RequestOption) *Response {
+	return dfltBuilder.GetStream(url, opts...)
+}
+
+var streamOption = optionFunc(func(o *reqOptions) {
+	o.stream = true
+})
+
+// maxErrorBodySize is the length at which the bodies of failed streams are
+// cut, if there is no WithMaxBodySize.
+const maxErrorBodySize = 1 << 20
+
+// doStream issues request with client, whose time out applies only until the
+// response headers arrive. The body can then take as long as needed.
+func doStream(client *http.Client, request *http.Request) (*http.Response, error) {
+
+	timeout := client.Timeout
+	if timeout <= 0 {
+		return client.Do(request)
+	}
+
+	c := *client
+	c.Timeout = 0
+
+	ctx, cancel := context.WithCancel(request.Context())
+	timer := time.AfterFunc(timeout, cancel)
+
+	httpResp, err := c.Do(request.WithContext(ctx))
+
+	// The timer went off, so the request (or the body) was canceled
+	if !timer.Stop() {
+		if err == nil {
+			httpResp.Body.Close()
+		}
+
+		return nil, &url.Error{
+			Op:  request.Method[:1] + strings.ToLower(request.Method[1:]),
+			URL: request.URL.String(),
+			Err: errHeaderTimeout{},
+		}
+	}
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	httpResp.Body = &cancelBody{ReadCloser: httpResp.Body, cancel: cancel}
+
+	return httpResp, nil
+}
+
+// errHeaderTimeout is the error of a stream whose response headers did not
+// arrive in time.
+type errHeaderTimeout struct{}
+
+func (errHeaderTimeout) Error() string   { return "timeout awaiting response headers" }
+func (errHeaderTimeout) Timeout() bool   { return true }
+func (errHeaderTimeout) Temporary() bool { return true }
+
+// cancelBody releases the context of the request once the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+// stream sets the live body of httpResp to the response, limited to max
+// bytes. Failed responses are read, up to maxErrorBodySize if there is no max.
+func (rb *RequestBuilder) stream(response *Response, httpResp *http.Response, max int64) {
+
+	if max > 0 && httpResp.ContentLength > max {
+		httpResp.Body.Close()
+		response.Err = ErrBodyTooLarge
+		return
+	}
+
+	response.success = rb.SuccessRange
+	if !response.successRange().Contains(httpResp.StatusCode) {
+
+		defer httpResp.Body.Close()
+		if max <= 0 {
+			httpResp.Body = ioutil.NopCloser(io.LimitReader(httpResp.Body, maxErrorBodySize))
+		}
+
+		body, err := readBody(httpResp, max)
+		if err != nil {
+			response.Err = err
+			return
+		}
+
+		httpResp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		response.Response = httpResp
+		response.byteBody = body
+		return
+	}
+
+	if max > 0 {
+		httpResp.Body = &limitedBody{ReadCloser: httpResp.Body, left: max}
+	}
+
+	response.Response = httpResp
+}
+
+// readBody reads the whole body of httpResp, up to max bytes.
+func readBody(httpResp *http.Response, max int64) ([]byte, error) {
+
+	if max <= 0 {
+		return ioutil.ReadAll(httpResp.Body)
+	}
+
+	if httpResp.ContentLength > max {
+		return nil, ErrBodyTooLarge
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(httpResp.Body, max+1))
+	if err == nil && int64(len(body)) > max {
+		return nil, ErrBodyTooLarge
+	}
+
+	return body, err
+}
+
+// limitedBody fails with ErrBodyTooLarge once more than left bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	left int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+
+	// Anything past the limit means the body is too large
+	if b.left <= 0 {
+		var one [1]byte
+		if n, err := b.ReadCloser.Read(one[:]); n == 0 {
+			return 0, err
+		}
+
+		return 0, ErrBodyTooLarge
+	}
+
+	if int64(len(p)) > b.left {
+		p = p[:b.left]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.left -= int64(n)
+
+	return n, err
+}
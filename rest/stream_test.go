@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetStream(t *testing.T) {
+
+	ms := NewMockServer(t)
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/exports/1",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespHeaders:  http.Header{"Cache-Control": {"max-age=60"}},
+		RespBody:     strings.Repeat("x", 1000),
+	}, &Mock{
+		URL:          "http://mytest.com/exports/2",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusNotFound,
+		RespHeaders:  http.Header{"Content-Type": {"application/json"}},
+		RespBody:     `{"message":"not found"}`,
+	})
+
+	rb := &RequestBuilder{MockServer: ms}
+
+	for i := 0; i < 2; i++ {
+		resp := rb.GetStream("http://mytest.com/exports/1")
+		if resp.Err != nil || resp.StatusCode != http.StatusOK || resp.CacheHit() {
+			t.Fatal("Streams should not be cached", resp.Err)
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if err != nil || len(body) != 1000 || len(resp.Bytes()) != 0 {
+			t.Fatal("Wrong body", err, len(body))
+		}
+	}
+
+	if calls := ms.MockCalls(); len(calls) != 2 {
+		t.Fatal("Both streams should reach the server", len(calls))
+	}
+
+	resp := rb.GetStream("http://mytest.com/exports/1", WithMaxBodySize(100))
+	if resp.Err != ErrBodyTooLarge || resp.Response != nil {
+		t.Fatal("Content-Length over the limit should fail", resp.Err)
+	}
+
+	resp = rb.Get("http://mytest.com/exports/1", WithMaxBodySize(100), WithoutCache())
+	if resp.Err != ErrBodyTooLarge {
+		t.Fatal("Bodies over the limit should fail", resp.Err)
+	}
+
+	resp = rb.GetStream("http://mytest.com/exports/2")
+	defer resp.Body.Close()
+
+	var httpErr *HTTPError
+	if err := resp.Error(); !errors.As(err, &httpErr) || !IsNotFound(err) ||
+		string(httpErr.Body) != `{"message":"not found"}` {
+		t.Fatal("Failed streams should have their body read", err)
+	}
+}
+
+func TestGetStreamErrorBody(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(strings.Repeat("x", maxErrorBodySize+1000)))
+	}))
+	defer server.Close()
+
+	resp := GetStream(server.URL)
+	if resp.Err != nil || len(resp.Bytes()) != maxErrorBodySize {
+		t.Fatal("Failed streams should be read up to 1 MiB", resp.Err, len(resp.Bytes()))
+	}
+
+	resp = GetStream(server.URL, WithMaxBodySize(100))
+	if resp.Err != ErrBodyTooLarge {
+		t.Fatal("Failed streams should fail over the max body size", resp.Err)
+	}
+}
+
+func TestGetStreamChunked(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for i := 0; i < 10; i++ {
+			w.Write([]byte(strings.Repeat("x", 100)))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	resp := GetStream(server.URL, WithMaxBodySize(1000))
+	if resp.Err != nil || resp.ContentLength != -1 {
+		t.Fatal("Body should be chunked", resp.Err, resp.ContentLength)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil || len(body) != 1000 {
+		t.Fatal("Bodies up to the limit should be read", err, len(body))
+	}
+
+	resp = GetStream(server.URL, WithMaxBodySize(999))
+	if resp.Err != nil {
+		t.Fatal(resp.Err)
+	}
+
+	body, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != ErrBodyTooLarge || len(body) != 999 {
+		t.Fatal("Bodies over the limit should fail when read", err, len(body))
+	}
+}
+
+func TestGetStreamTimeout(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow/headers" {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		for i := 0; i < 3; i++ {
+			w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	rb := &RequestBuilder{BaseURL: server.URL, Timeout: 150 * time.Millisecond}
+
+	resp := rb.GetStream("/slow/body")
+	if resp.Err != nil {
+		t.Fatal(resp.Err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil || string(body) != "chunkchunkchunk" {
+		t.Fatal("The time out should not apply to the body", err)
+	}
+
+	if resp = rb.GetStream("/slow/headers"); !IsRetryable(resp.Err) {
+		t.Fatal("The time out should apply to the headers", resp.Err)
+	}
+
+	if resp = rb.Get("/slow/body"); resp.Err == nil {
+		t.Fatal("The time out should apply to the body of other requests")
+	}
+}
@@ -0,0 +1,130 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotCompleted is the error of a Future whose ForkJoin operation is not
+// completed yet.
+var ErrNotCompleted = errors.New("ForkJoin operation not completed")
+
+// GetAs issues a GET HTTP verb to the specified URL, and decodes the
+// response into a T. Transport errors, status codes out of the success
+// range of rb (a *HTTPError), and decoding errors are all returned as the
+// error. The Response is returned
+// anyway, if any.
+//
+//	user, resp, err := rest.GetAs[User](rb, "http://api.com/users/1")
+//
+// T could be a string or a []byte too, to get the body as is. Empty bodies
+// are the zero value of T. If rb is nil, the DefaultBuilder is used.
+func GetAs[T any](rb *RequestBuilder, url string, opts ...RequestOption) (T, *Response, error) {
+	return decodeAs[T](builder(rb).Get(url, opts...))
+}
+
+// DeleteAs issues a DELETE HTTP verb to the specified URL, and decodes the
+// response into a T. See GetAs
+func DeleteAs[T any](rb *RequestBuilder, url string, opts ...RequestOption) (T, *Response, error) {
+	return decodeAs[T](builder(rb).Delete(url, opts...))
+}
+
+// PostAs issues a POST HTTP verb to the specified URL, with the body, and
+// decodes the response into a Resp. See GetAs
+//
+// The type of the body is inferred, so only Resp has to be given.
+//
+//	created, resp, err := rest.PostAs[User](rb, "http://api.com/users", &User{Name: "Alice"})
+func PostAs[Resp any, Req any](rb *RequestBuilder, url string, body Req, opts ...RequestOption) (Resp, *Response, error) {
+	return decodeAs[Resp](builder(rb).Post(url, body, opts...))
+}
+
+// PutAs issues a PUT HTTP verb to the specified URL, with the body, and
+// decodes the response into a Resp. See GetAs
+func PutAs[Resp any, Req any](rb *RequestBuilder, url string, body Req, opts ...RequestOption) (Resp, *Response, error) {
+	return decodeAs[Resp](builder(rb).Put(url, body, opts...))
+}
+
+// PatchAs issues a PATCH HTTP verb to the specified URL, with the body, and
+// decodes the response into a Resp. See GetAs
+func PatchAs[Resp any, Req any](rb *RequestBuilder, url string, body Req, opts ...RequestOption) (Resp, *Response, error) {
+	return decodeAs[Resp](builder(rb).Patch(url, body, opts...))
+}
+
+// Future is a FutureResponse, whose Response is decoded into a T.
+//
+//	var user *rest.Future[User]
+//
+//	rest.ForkJoin(func(c *rest.Concurrent) {
+//		user = rest.FutureOf[User](c.Get("http://api.com/users/1"))
+//	})
+//
+//	u, resp, err := user.Get()
+type Future[T any] struct {
+	fr *FutureResponse
+}
+
+// FutureOf decodes the Response of a FutureResponse into a T.
+//
+// Methods can't have type parameters, so Concurrent has no typed versions of
+// Get, Post and the rest: FutureOf wraps the FutureResponse of any of them.
+func FutureOf[T any](fr *FutureResponse) *Future[T] {
+	return &Future[T]{fr: fr}
+}
+
+// Response gives you the Response of the Request, after the ForkJoin
+// operation is completed.
+func (f *Future[T]) Response() *Response {
+	return f.fr.Response()
+}
+
+// Get decodes the Response into a T, as GetAs does. The error is
+// ErrNotCompleted if the ForkJoin operation is not completed.
+func (f *Future[T]) Get() (T, *Response, error) {
+
+	resp := f.fr.Response()
+	if resp == nil {
+		var zero T
+		return zero, nil, ErrNotCompleted
+	}
+
+	return decodeAs[T](resp)
+}
+
+func builder(rb *RequestBuilder) *RequestBuilder {
+
+	if rb == nil {
+		return &dfltBuilder
+	}
+
+	return rb
+}
+
+// decodeAs decodes the response into a T, unless it failed.
+func decodeAs[T any](resp *Response) (T, *Response, error) {
+
+	var v T
+
+	if err := resp.Error(); err != nil {
+		return v, resp, err
+	}
+
+	switch p := interface{}(&v).(type) {
+	case *string:
+		*p = resp.String()
+		return v, resp, nil
+	case *[]byte:
+		*p = resp.Bytes()
+		return v, resp, nil
+	}
+
+	if len(resp.Bytes()) == 0 {
+		return v, resp, nil
+	}
+
+	if err := resp.FillUp(&v); err != nil {
+		return v, resp, fmt.Errorf("decoding the response: %w", err)
+	}
+
+	return v, resp, nil
+}
@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestGetAs(t *testing.T) {
+
+	user, resp, err := GetAs[User](&rb, "/user/1")
+	if err != nil || resp.StatusCode != http.StatusOK || user.Name != "Hernan" {
+		t.Fatal("Wrong user", user, err)
+	}
+
+	users, _, err := GetAs[[]User](nil, server.URL+"/user")
+	if err != nil || len(users) != len(userList) {
+		t.Fatal("Wrong users", err)
+	}
+
+	body, _, err := GetAs[string](&rb, "/user/1")
+	if err != nil || body == "" {
+		t.Fatal("Wrong body", err)
+	}
+
+	ms := NewMockServer(t)
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/typed/missing",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusNotFound,
+	})
+
+	_, resp, err = GetAs[User](&RequestBuilder{MockServer: ms}, "http://mytest.com/typed/missing")
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound || resp.StatusCode != http.StatusNotFound {
+		t.Fatal("Non 2xx statuses should fail", err)
+	}
+
+	if _, _, err = GetAs[int](&rb, "/user/1"); err == nil {
+		t.Fatal("Decoding errors should fail")
+	}
+
+	if _, _, err = GetAs[User](&RequestBuilder{}, "http://invalid.invalid/user"); err == nil || errors.As(err, &httpErr) {
+		t.Fatal("Transport errors should fail", err)
+	}
+}
+
+func TestPostAs(t *testing.T) {
+
+	created, resp, err := PostAs[User](&rb, "/user", &User{Name: "Matilda"})
+	if err != nil || resp.StatusCode != http.StatusCreated || created.Name != "Matilda" {
+		t.Fatal("Wrong user", created, err)
+	}
+
+	if _, _, err = PutAs[User](&rb, "/user/3", &User{Name: "Pichucha"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = PatchAs[User](&rb, "/user/3", &User{Name: "Pichucha"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = DeleteAs[string](&rb, "/user/4"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFutureOf(t *testing.T) {
+
+	ms := NewMockServer(t)
+	ms.AddMockups(&Mock{
+		URL:          "http://mytest.com/typed/missing",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusNotFound,
+	})
+
+	var user *Future[User]
+	var missing *Future[User]
+
+	rb.ForkJoin(func(c *Concurrent) {
+		user = FutureOf[User](c.Get("/user/2"))
+	})
+
+	mb := &RequestBuilder{MockServer: ms}
+	mb.ForkJoin(func(c *Concurrent) {
+		missing = FutureOf[User](c.Get("http://mytest.com/typed/missing"))
+
+		if _, _, err := missing.Get(); err != ErrNotCompleted {
+			t.Error("The Future should not be completed", err)
+		}
+	})
+
+	if u, _, err := user.Get(); err != nil || u.Name != "Hernan" {
+		t.Fatal("Wrong user", u, err)
+	}
+
+	if _, resp, err := missing.Get(); err == nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatal("Non 2xx statuses should fail", err)
+	}
+}
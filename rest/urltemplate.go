@@ -0,0 +1,381 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Params holds the values for expanding a URL template, as defined by
+// RFC 6570 (https://tools.ietf.org/html/rfc6570).
+// Params is a RequestOption, so it can be passed to any request.
+//
+//	resp := rb.Get("/users/{id}/items{?limit,offset}", rest.Params{
+//		"id":     "hernan",
+//		"limit":  10,
+//		"offset": 20,
+//	})
+//
+// Values could be strings, numbers, booleans, slices (lists) & maps
+// (associative arrays). Missing and nil values are undefined, and are
+// skipped as the RFC states.
+type Params map[string]interface{}
+
+func (p Params) apply(o *reqOptions) {
+
+	if o.params == nil {
+		o.params = make(Params, len(p))
+	}
+
+	for k, v := range p {
+		o.params[k] = v
+	}
+}
+
+// An operator of a template expression, as in RFC 6570, section 3.2.1
+type templateOperator struct {
+	first    string
+	sep      string
+	named    bool
+	ifEmpty  string
+	reserved bool
+}
+
+var templateOperators = map[byte]templateOperator{
+	'+': {"", ",", false, "", true},
+	'#': {"#", ",", false, "", true},
+	'.': {".", ".", false, "", false},
+	'/': {"/", "/", false, "", false},
+	';': {";", ";", true, "", false},
+	'?': {"?", "&", true, "=", false},
+	'&': {"&", "&", true, "=", false},
+}
+
+var simpleOperator = templateOperator{"", ",", false, "", false}
+
+// expandURL expands a URL template with the given params.
+func expandURL(template string, params Params) (string, error) {
+
+	var result strings.Builder
+
+	for {
+		open := strings.IndexByte(template, '{')
+		if open < 0 {
+			result.WriteString(template)
+			break
+		}
+
+		end := strings.IndexByte(template[open:], '}')
+		if end < 0 {
+			return "", errors.New("URL template: unclosed expression in " + template)
+		}
+
+		result.WriteString(template[:open])
+
+		expanded, err := expandExpression(template[open+1:open+end], params)
+		if err != nil {
+			return "", err
+		}
+
+		result.WriteString(expanded)
+		template = template[open+end+1:]
+	}
+
+	return result.String(), nil
+}
+
+func expandExpression(expression string, params Params) (string, error) {
+
+	if expression == "" {
+		return "", errors.New("URL template: empty expression")
+	}
+
+	op := simpleOperator
+	if o, ok := templateOperators[expression[0]]; ok {
+		op = o
+		expression = expression[1:]
+	}
+
+	var result strings.Builder
+	first := true
+
+	for _, varSpec := range strings.Split(expression, ",") {
+
+		name, explode, prefix, err := parseVarSpec(varSpec)
+		if err != nil {
+			return "", err
+		}
+
+		value, defined := templateValue(params[name])
+		if !defined {
+			continue
+		}
+
+		if first {
+			result.WriteString(op.first)
+			first = false
+		} else {
+			result.WriteString(op.sep)
+		}
+
+		switch v := value.(type) {
+
+		case string:
+			if prefix > 0 {
+				v = runePrefix(v, prefix)
+			}
+			writeNamed(&result, op, name, v == "")
+			result.WriteString(templateEscape(v, op.reserved))
+
+		case []string:
+			switch {
+			case !explode:
+				writeNamed(&result, op, name, false)
+				for i, item := range v {
+					if i > 0 {
+						result.WriteString(",")
+					}
+					result.WriteString(templateEscape(item, op.reserved))
+				}
+			default:
+				for i, item := range v {
+					if i > 0 {
+						result.WriteString(op.sep)
+					}
+					if op.named {
+						writeNamed(&result, op, name, item == "")
+					}
+					result.WriteString(templateEscape(item, op.reserved))
+				}
+			}
+
+		case [][2]string:
+			switch {
+			case !explode:
+				writeNamed(&result, op, name, false)
+				for i, pair := range v {
+					if i > 0 {
+						result.WriteString(",")
+					}
+					result.WriteString(templateEscape(pair[0], op.reserved))
+					result.WriteString(",")
+					result.WriteString(templateEscape(pair[1], op.reserved))
+				}
+			default:
+				for i, pair := range v {
+					if i > 0 {
+						result.WriteString(op.sep)
+					}
+					result.WriteString(templateEscape(pair[0], op.reserved))
+					if pair[1] == "" {
+						result.WriteString(op.ifEmpty)
+						continue
+					}
+					result.WriteString("=")
+					result.WriteString(templateEscape(pair[1], op.reserved))
+				}
+			}
+		}
+	}
+
+	return result.String(), nil
+}
+
+func parseVarSpec(varSpec string) (name string, explode bool, prefix int, err error) {
+
+	name = varSpec
+
+	switch {
+	case strings.HasSuffix(name, "*"):
+		name, explode = name[:len(name)-1], true
+
+	case strings.Contains(name, ":"):
+		i := strings.Index(name, ":")
+		if _, err = fmt.Sscanf(name[i+1:], "%d", &prefix); err != nil || prefix <= 0 || prefix >= 10000 {
+			return "", false, 0, errors.New("URL template: wrong prefix in " + varSpec)
+		}
+		name = name[:i]
+	}
+
+	if name == "" {
+		return "", false, 0, errors.New("URL template: empty variable name")
+	}
+
+	return
+}
+
+// writeNamed writes the name of the variable for named operators
+// (path-style parameters and queries).
+func writeNamed(result *strings.Builder, op templateOperator, name string, empty bool) {
+
+	if !op.named {
+		return
+	}
+
+	result.WriteString(templateEscape(name, true))
+
+	if empty {
+		result.WriteString(op.ifEmpty)
+		return
+	}
+
+	result.WriteString("=")
+}
+
+// templateValue converts a param to one of: string, []string (lists) or
+// [][2]string (associative arrays, sorted by key).
+// Undefined values, and empty lists and maps, return false.
+func templateValue(param interface{}) (interface{}, bool) {
+
+	switch p := param.(type) {
+	case nil:
+		return nil, false
+	case string:
+		return p, true
+	case []byte:
+		return string(p), true
+	}
+
+	v := reflect.ValueOf(param)
+
+	switch v.Kind() {
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+		return templateValue(v.Elem().Interface())
+
+	case reflect.Slice, reflect.Array:
+		list := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			list = append(list, fmt.Sprint(v.Index(i).Interface()))
+		}
+
+		return list, len(list) > 0
+
+	case reflect.Map:
+		keys := v.MapKeys()
+		pairs := make([][2]string, 0, len(keys))
+
+		for _, k := range keys {
+			pairs = append(pairs, [2]string{fmt.Sprint(k.Interface()), fmt.Sprint(v.MapIndex(k).Interface())})
+		}
+
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+
+		return pairs, len(pairs) > 0
+	}
+
+	return fmt.Sprint(param), true
+}
+
+func runePrefix(s string, n int) string {
+
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+
+	return s
+}
+
+// templateEscape percent-encodes everything but the unreserved characters.
+// If reserved is true, reserved characters and percent-encoded triplets are
+// kept as they are.
+func templateEscape(s string, reserved bool) string {
+
+	const hex = "0123456789ABCDEF"
+	var result strings.Builder
+
+	for i := 0; i < len(s); i++ {
+
+		c := s[i]
+
+		switch {
+		case isUnreserved(c):
+			result.WriteByte(c)
+			continue
+		case reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			result.WriteByte(c)
+			continue
+		case reserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			result.WriteString(s[i : i+3])
+			i += 2
+			continue
+		}
+
+		result.WriteByte('%')
+		result.WriteByte(hex[c>>4])
+		result.WriteByte(hex[c&15])
+	}
+
+	return result.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// joinURL joins the RequestBuilder BaseURL and the URL of a request, with
+// exactly one slash between them.
+func joinURL(base string, path string) string {
+
+	switch {
+	case base == "":
+		return path
+	case path == "":
+		return base
+	case path[0] == '?' || path[0] == '#':
+		return base + path
+	}
+
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// normalizeURL returns a canonical form of the URL, so equivalent URLs share
+// the same cache key: lower case scheme and host, no default port, no
+// fragment, and sorted query parameters.
+func normalizeURL(rawURL string) string {
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Opaque != "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	switch {
+	case u.Scheme == "http" && strings.HasSuffix(u.Host, ":80"):
+		u.Host = strings.TrimSuffix(u.Host, ":80")
+	case u.Scheme == "https" && strings.HasSuffix(u.Host, ":443"):
+		u.Host = strings.TrimSuffix(u.Host, ":443")
+	}
+
+	if u.Path == "" && u.Host != "" {
+		u.Path = "/"
+	}
+
+	if u.RawQuery != "" {
+		if query, err := url.ParseQuery(u.RawQuery); err == nil {
+			u.RawQuery = query.Encode()
+		}
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.ForceQuery = false
+
+	return u.String()
+}
@@ -0,0 +1,175 @@
+package rest
+
+import (
+	"net/http"
+	"testing"
+)
+
+// Examples from RFC 6570, section 3.2
+var templateParams = Params{
+	"count":      []string{"one", "two", "three"},
+	"dom":        []string{"example", "com"},
+	"dub":        "me/too",
+	"hello":      "Hello World!",
+	"half":       "50%",
+	"var":        "value",
+	"who":        "fred",
+	"base":       "http://example.com/home/",
+	"path":       "/foo/bar",
+	"list":       []string{"red", "green", "blue"},
+	"keys":       map[string]string{"semi": ";", "dot": ".", "comma": ","},
+	"v":          6,
+	"x":          1024,
+	"y":          768,
+	"empty":      "",
+	"empty_keys": map[string]string{},
+	"undef":      nil,
+}
+
+var templateTests = []struct {
+	template string
+	expanded string
+}{
+	{"{var}", "value"},
+	{"{hello}", "Hello%20World%21"},
+	{"{half}", "50%25"},
+	{"O{empty}X", "OX"},
+	{"O{undef}X", "OX"},
+	{"{x,y}", "1024,768"},
+	{"{x,hello,y}", "1024,Hello%20World%21,768"},
+	{"?{x,empty}", "?1024,"},
+	{"?{x,undef}", "?1024"},
+	{"{var:3}", "val"},
+	{"{var:30}", "value"},
+	{"{list}", "red,green,blue"},
+	{"{list*}", "red,green,blue"},
+	{"{keys}", "comma,%2C,dot,.,semi,%3B"},
+	{"{keys*}", "comma=%2C,dot=.,semi=%3B"},
+	{"{+var}", "value"},
+	{"{+hello}", "Hello%20World!"},
+	{"{+half}", "50%25"},
+	{"{base}index", "http%3A%2F%2Fexample.com%2Fhome%2Findex"},
+	{"{+base}index", "http://example.com/home/index"},
+	{"{+path}/here", "/foo/bar/here"},
+	{"here?ref={+path}", "here?ref=/foo/bar"},
+	{"{#var}", "#value"},
+	{"{#hello}", "#Hello%20World!"},
+	{"{#path:6}/here", "#/foo/b/here"},
+	{"X{.var}", "X.value"},
+	{"X{.x,y}", "X.1024.768"},
+	{"X{.list*}", "X.red.green.blue"},
+	{"{/var}", "/value"},
+	{"{/var,x}/here", "/value/1024/here"},
+	{"{/list*,path:4}", "/red/green/blue/%2Ffoo"},
+	{"{/keys*}", "/comma=%2C/dot=./semi=%3B"},
+	{"{;x,y}", ";x=1024;y=768"},
+	{"{;x,y,empty}", ";x=1024;y=768;empty"},
+	{"{;list*}", ";list=red;list=green;list=blue"},
+	{"{;keys*}", ";comma=%2C;dot=.;semi=%3B"},
+	{"{?x,y}", "?x=1024&y=768"},
+	{"{?x,y,empty}", "?x=1024&y=768&empty="},
+	{"{?list}", "?list=red,green,blue"},
+	{"{?list*}", "?list=red&list=green&list=blue"},
+	{"{?keys*}", "?comma=%2C&dot=.&semi=%3B"},
+	{"{?empty_keys*}", ""},
+	{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+	{"{&var:3}", "&var=val"},
+	{"/users/{who}/items{?v,undef}", "/users/fred/items?v=6"},
+}
+
+func TestExpandURL(t *testing.T) {
+
+	for _, tt := range templateTests {
+
+		expanded, err := expandURL(tt.template, templateParams)
+		if err != nil {
+			t.Fatal(tt.template + ": " + err.Error())
+		}
+
+		if expanded != tt.expanded {
+			t.Fatal(tt.template + " expanded to " + expanded + ", expected " + tt.expanded)
+		}
+	}
+}
+
+func TestExpandURLErrors(t *testing.T) {
+
+	for _, template := range []string{"/users/{id", "/users/{}", "{var:x}", "{,}"} {
+		if _, err := expandURL(template, templateParams); err == nil {
+			t.Fatal("Template should fail: " + template)
+		}
+	}
+}
+
+func TestJoinURL(t *testing.T) {
+
+	tests := [][3]string{
+		{"", "/user", "/user"},
+		{"http://api.com", "", "http://api.com"},
+		{"http://api.com", "/user", "http://api.com/user"},
+		{"http://api.com/", "/user", "http://api.com/user"},
+		{"http://api.com/v1/", "user", "http://api.com/v1/user"},
+		{"http://api.com/v1", "user", "http://api.com/v1/user"},
+		{"http://api.com/user", "?id=1", "http://api.com/user?id=1"},
+	}
+
+	for _, tt := range tests {
+		if url := joinURL(tt[0], tt[1]); url != tt[2] {
+			t.Fatal(tt[0] + " + " + tt[1] + " joined to " + url + ", expected " + tt[2])
+		}
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+
+	tests := [][2]string{
+		{"HTTP://API.com:80/user?b=2&a=1#frag", "http://api.com/user?a=1&b=2"},
+		{"https://api.com:443", "https://api.com/"},
+		{"http://api.com:8080/user?a=%7E", "http://api.com:8080/user?a=~"},
+		{"/user?b=2&a=1", "/user?a=1&b=2"},
+	}
+
+	for _, tt := range tests {
+		if url := normalizeURL(tt[0]); url != tt[1] {
+			t.Fatal(tt[0] + " normalized to " + url + ", expected " + tt[1])
+		}
+	}
+}
+
+func TestGetURLTemplate(t *testing.T) {
+
+	var echo Echo
+
+	builder := RequestBuilder{
+		BaseURL: server.URL + "/",
+	}
+
+	resp := builder.Get("/echo/{id}/items{?limit,offset}", Params{
+		"id":    "a b/c",
+		"limit": 10,
+	}, WithQuery("q", "x"))
+
+	if err := resp.FillUp(&echo); err != nil {
+		t.Fatal(err)
+	}
+
+	if echo.URL != "/echo/a%20b%2Fc/items?limit=10&q=x" {
+		t.Fatal("Wrong URL: " + echo.URL)
+	}
+}
+
+func TestEquivalentURLsShareCache(t *testing.T) {
+
+	resp := rb.Get("/echo?cache-control=max-age=10&test=normalize&a=1")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("Status != OK (200)")
+	}
+
+	if !rb.Get("/echo?a=1&test=normalize&cache-control=max-age=10#fragment").CacheHit() {
+		t.Fatal("Equivalent URLs should share the cache")
+	}
+
+	if !rb.Get("/echo{?test,a}&cache-control=max-age=10", Params{"a": 1, "test": "normalize"}).CacheHit() {
+		t.Fatal("Equivalent URLs should share the cache")
+	}
+}